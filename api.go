@@ -0,0 +1,48 @@
+package sendgrid
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/sendgrid/rest"
+)
+
+// doJSON sends a request to endpoint with in, if not nil, as its JSON body
+// and decodes the JSON response into out, if not nil. Non-2xx responses are
+// returned as *APIError whatever ReturnAPIErrors is set to, along with the
+// response; for a 403 its RequiredScopes lists the scopes of the endpoint.
+func (cl *Client) doJSON(ctx context.Context, method rest.Method, endpoint string, queryParams map[string]string, in, out interface{}) (*rest.Response, error) {
+	request := cl.GetRequest(endpoint)
+	request.Method = method
+	if len(queryParams) > 0 {
+		request.QueryParams = queryParams
+	}
+	return cl.doRequestJSON(ctx, request, in, out)
+}
+
+// doRequestJSON is like doJSON for a request built by the caller
+func (cl *Client) doRequestJSON(ctx context.Context, request rest.Request, in, out interface{}) (*rest.Response, error) {
+	if in != nil {
+		body, err := json.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+		request.Body = body
+	}
+
+	// MakeRequestWithContext already returns the *APIError with
+	// ReturnAPIErrors or an exhausted RetryPolicy
+	response, err := cl.MakeRequestWithContext(ctx, request)
+	if err == nil {
+		err = CheckResponse(response)
+	}
+	if err != nil {
+		return response, withRequiredScopes(err, request)
+	}
+	if out != nil && response.Body != "" {
+		if err := json.Unmarshal([]byte(response.Body), out); err != nil {
+			return response, err
+		}
+	}
+	return response, nil
+}
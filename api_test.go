@@ -0,0 +1,56 @@
+package sendgrid
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sendgrid/rest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDoJSON(t *testing.T) {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var in map[string]string
+		json.NewDecoder(r.Body).Decode(&in)
+		json.NewEncoder(w).Encode(map[string]string{
+			"method": r.Method,
+			"limit":  r.URL.Query().Get("limit"),
+			"name":   in["name"],
+		})
+	}))
+	defer fakeServer.Close()
+	client := New("SENDGRID_APIKEY", WithHost(fakeServer.URL))
+
+	var out map[string]string
+	response, err := client.doJSON(context.Background(), rest.Put, "/v3/resource", map[string]string{"limit": "10"}, map[string]string{"name": "value"}, &out)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, map[string]string{"method": "PUT", "limit": "10", "name": "value"}, out)
+}
+
+func TestDoJSON_errors(t *testing.T) {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v3/invalid" {
+			w.Write([]byte("not json"))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"errors":[{"message":"resource not found"}]}`))
+	}))
+	defer fakeServer.Close()
+	client := New("SENDGRID_APIKEY", WithHost(fakeServer.URL))
+
+	var out map[string]string
+	response, err := client.doJSON(context.Background(), rest.Get, "/v3/missing", nil, nil, &out)
+	assert.True(t, IsNotFound(err), "API errors should be returned without ReturnAPIErrors")
+	assert.Equal(t, 404, response.StatusCode)
+
+	_, err = client.doJSON(context.Background(), rest.Get, "/v3/invalid", nil, nil, &out)
+	assert.NotNil(t, err)
+
+	_, err = client.doJSON(context.Background(), rest.Post, "/v3/invalid", nil, make(chan int), nil)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,94 @@
+package sendgrid
+
+import (
+	"context"
+	"errors"
+	"net/url"
+
+	"github.com/sendgrid/rest"
+)
+
+// APIKey is an API key of the account. Key, the secret, is only returned
+// when the key is created.
+type APIKey struct {
+	ID     string `json:"api_key_id"`
+	Name   string `json:"name"`
+	Scopes Scopes `json:"scopes,omitempty"`
+	Key    string `json:"api_key,omitempty"`
+}
+
+// APIKeysService manages the API keys of the account through /v3/api_keys
+type APIKeysService struct {
+	client *Client
+}
+
+// APIKeys returns the service managing the API keys of the account
+func (cl *Client) APIKeys() *APIKeysService {
+	return &APIKeysService{client: cl}
+}
+
+// Create creates an API key with the given scopes. The returned key holds
+// the secret, which can not be retrieved later.
+func (s *APIKeysService) Create(ctx context.Context, name string, scopes Scopes) (*APIKey, error) {
+	var key APIKey
+	in := &APIKey{Name: name, Scopes: scopes}
+	if _, err := s.client.doJSON(ctx, rest.Post, "/v3/api_keys", nil, in, &key); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// List lists the API keys of the account, without their scopes
+func (s *APIKeysService) List(ctx context.Context) ([]*APIKey, error) {
+	var keys struct {
+		Result []*APIKey `json:"result"`
+	}
+	if _, err := s.client.doJSON(ctx, rest.Get, "/v3/api_keys", nil, nil, &keys); err != nil {
+		return nil, err
+	}
+	return keys.Result, nil
+}
+
+// Get returns an API key with its scopes
+func (s *APIKeysService) Get(ctx context.Context, id string) (*APIKey, error) {
+	var keys struct {
+		Result []*APIKey `json:"result"`
+	}
+	if _, err := s.client.doJSON(ctx, rest.Get, apiKeyEndpoint(id), nil, nil, &keys); err != nil {
+		return nil, err
+	}
+	if len(keys.Result) == 0 {
+		return nil, errors.New("sendgrid: no API key in the response")
+	}
+	return keys.Result[0], nil
+}
+
+// Update replaces the name and scopes of an API key
+func (s *APIKeysService) Update(ctx context.Context, id, name string, scopes Scopes) (*APIKey, error) {
+	var key APIKey
+	in := &APIKey{Name: name, Scopes: scopes}
+	if _, err := s.client.doJSON(ctx, rest.Put, apiKeyEndpoint(id), nil, in, &key); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// Rename changes the name of an API key, keeping its scopes
+func (s *APIKeysService) Rename(ctx context.Context, id, name string) (*APIKey, error) {
+	var key APIKey
+	in := map[string]string{"name": name}
+	if _, err := s.client.doJSON(ctx, rest.Patch, apiKeyEndpoint(id), nil, in, &key); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// Delete revokes an API key
+func (s *APIKeysService) Delete(ctx context.Context, id string) error {
+	_, err := s.client.doJSON(ctx, rest.Delete, apiKeyEndpoint(id), nil, nil, nil)
+	return err
+}
+
+func apiKeyEndpoint(id string) string {
+	return "/v3/api_keys/" + url.PathEscape(id)
+}
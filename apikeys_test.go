@@ -0,0 +1,126 @@
+package sendgrid
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeAPIKeys emulates the api_keys endpoints
+type fakeAPIKeys struct {
+	*httptest.Server
+	mu   sync.Mutex
+	keys map[string]*APIKey
+	next int
+}
+
+func newFakeAPIKeys() *fakeAPIKeys {
+	s := &fakeAPIKeys{keys: make(map[string]*APIKey)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+func (s *fakeAPIKeys) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var in APIKey
+	json.NewDecoder(r.Body).Decode(&in)
+	id := strings.TrimPrefix(r.URL.Path, "/v3/api_keys/")
+	key, found := s.keys[id]
+	switch {
+	case r.Method == "POST" && r.URL.Path == "/v3/api_keys":
+		s.next++
+		key := &APIKey{ID: fmt.Sprintf("key-%d", s.next), Name: in.Name, Scopes: in.Scopes}
+		s.keys[key.ID] = key
+		w.WriteHeader(http.StatusCreated)
+		created := *key
+		created.Key = "SG.secret"
+		json.NewEncoder(w).Encode(created)
+	case r.Method == "GET" && r.URL.Path == "/v3/api_keys":
+		result := []*APIKey{}
+		for _, key := range s.keys {
+			result = append(result, &APIKey{ID: key.ID, Name: key.Name})
+		}
+		json.NewEncoder(w).Encode(map[string][]*APIKey{"result": result})
+	case !found:
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"errors":[{"field":null,"message":"unable to find API Key"}]}`)
+	case r.Method == "GET":
+		json.NewEncoder(w).Encode(map[string][]*APIKey{"result": {key}})
+	case r.Method == "PUT":
+		key.Name, key.Scopes = in.Name, in.Scopes
+		json.NewEncoder(w).Encode(key)
+	case r.Method == "PATCH":
+		key.Name = in.Name
+		json.NewEncoder(w).Encode(&APIKey{ID: key.ID, Name: key.Name})
+	case r.Method == "DELETE":
+		delete(s.keys, id)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestAPIKeys(t *testing.T) {
+	fakeServer := newFakeAPIKeys()
+	defer fakeServer.Close()
+	keys := New("SENDGRID_APIKEY", WithHost(fakeServer.URL)).APIKeys()
+	ctx := context.Background()
+
+	created, err := keys.Create(ctx, "mailer", MailSendScopes)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, &APIKey{ID: "key-1", Name: "mailer", Scopes: Scopes{"mail.send"}, Key: "SG.secret"}, created)
+
+	list, err := keys.List(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, []*APIKey{{ID: "key-1", Name: "mailer"}}, list)
+
+	updated, err := keys.Update(ctx, "key-1", "scheduler", SchedulingScopes)
+	assert.Nil(t, err)
+	assert.Equal(t, SchedulingScopes, updated.Scopes)
+
+	renamed, err := keys.Rename(ctx, "key-1", "scheduler v2")
+	assert.Nil(t, err)
+	assert.Equal(t, "scheduler v2", renamed.Name)
+
+	key, err := keys.Get(ctx, "key-1")
+	assert.Nil(t, err)
+	assert.Equal(t, &APIKey{ID: "key-1", Name: "scheduler v2", Scopes: SchedulingScopes}, key, "Rename should keep the scopes")
+
+	assert.Nil(t, keys.Delete(ctx, "key-1"))
+	_, err = keys.Get(ctx, "key-1")
+	assert.True(t, IsNotFound(err))
+	assert.True(t, IsNotFound(keys.Delete(ctx, "key-1")))
+	_, err = keys.Update(ctx, "key-1", "name", nil)
+	assert.True(t, IsNotFound(err))
+	_, err = keys.Rename(ctx, "key-1", "name")
+	assert.True(t, IsNotFound(err))
+}
+
+func TestAPIKeys_errors(t *testing.T) {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" && r.URL.Path == "/v3/api_keys/empty" {
+			fmt.Fprint(w, `{"result":[]}`)
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"errors":[{"field":null,"message":"access forbidden"}]}`)
+	}))
+	defer fakeServer.Close()
+	keys := New("SENDGRID_APIKEY", WithHost(fakeServer.URL)).APIKeys()
+	ctx := context.Background()
+
+	_, err := keys.Get(ctx, "empty")
+	assert.NotNil(t, err)
+	_, err = keys.Create(ctx, "mailer", MailSendScopes)
+	assert.True(t, IsForbidden(err))
+	_, err = keys.List(ctx)
+	assert.True(t, IsForbidden(err))
+}
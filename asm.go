@@ -0,0 +1,212 @@
+package sendgrid
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+
+	"github.com/sendgrid/rest"
+	"github.com/sendgrid/sendgrid-go/helpers/mail"
+)
+
+// UnsubscribeGroup is a suppression group recipients can unsubscribe from
+// without unsubscribing from every message
+type UnsubscribeGroup struct {
+	ID          int    `json:"id,omitempty"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	IsDefault   bool   `json:"is_default"`
+	// Unsubscribes is the number of addresses suppressed from the group,
+	// it is not sent when creating or updating a group
+	Unsubscribes int `json:"unsubscribes,omitempty"`
+}
+
+// Asm returns the unsubscribe settings of a message sent to the group,
+// offering recipients the groups to display on the unsubscribe page
+func (g *UnsubscribeGroup) Asm(groupsToDisplay ...int) *mail.Asm {
+	asm := mail.NewASM().SetGroupID(g.ID)
+	if len(groupsToDisplay) > 0 {
+		asm.AddGroupsToDisplay(groupsToDisplay...)
+	}
+	return asm
+}
+
+// GroupSuppression is an address suppressed from a group
+type GroupSuppression struct {
+	Email     string `json:"email"`
+	GroupID   int    `json:"group_id"`
+	GroupName string `json:"group_name"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+// recipientEmails is the body of the requests adding or searching
+// suppressions
+type recipientEmails struct {
+	RecipientEmails []string `json:"recipient_emails"`
+}
+
+// ASMService manages unsubscribe groups and their suppressions through
+// /v3/asm
+type ASMService struct {
+	client *Client
+}
+
+// ASM returns the service managing unsubscribe groups and their
+// suppressions
+func (cl *Client) ASM() *ASMService {
+	return &ASMService{client: cl}
+}
+
+// CreateGroup creates an unsubscribe group, returning it with its ID
+func (s *ASMService) CreateGroup(ctx context.Context, group *UnsubscribeGroup) (*UnsubscribeGroup, error) {
+	var created UnsubscribeGroup
+	in := &UnsubscribeGroup{Name: group.Name, Description: group.Description, IsDefault: group.IsDefault}
+	if _, err := s.client.doJSON(ctx, rest.Post, "/v3/asm/groups", nil, in, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// ListGroups lists the unsubscribe groups of the account
+func (s *ASMService) ListGroups(ctx context.Context) ([]*UnsubscribeGroup, error) {
+	var groups []*UnsubscribeGroup
+	if _, err := s.client.doJSON(ctx, rest.Get, "/v3/asm/groups", nil, nil, &groups); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// GetGroup returns an unsubscribe group
+func (s *ASMService) GetGroup(ctx context.Context, id int) (*UnsubscribeGroup, error) {
+	var group UnsubscribeGroup
+	if _, err := s.client.doJSON(ctx, rest.Get, groupEndpoint(id), nil, nil, &group); err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// GroupUpdate holds the fields of an unsubscribe group UpdateGroup changes.
+// Fields left nil keep their value.
+type GroupUpdate struct {
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+	IsDefault   *bool   `json:"is_default,omitempty"`
+}
+
+// SetName ...
+func (u *GroupUpdate) SetName(name string) *GroupUpdate {
+	u.Name = &name
+	return u
+}
+
+// SetDescription ...
+func (u *GroupUpdate) SetDescription(description string) *GroupUpdate {
+	u.Description = &description
+	return u
+}
+
+// SetIsDefault ...
+func (u *GroupUpdate) SetIsDefault(isDefault bool) *GroupUpdate {
+	u.IsDefault = &isDefault
+	return u
+}
+
+// UpdateGroup changes the fields of the group with the given ID that are
+// set in update, returning the updated group
+func (s *ASMService) UpdateGroup(ctx context.Context, id int, update *GroupUpdate) (*UnsubscribeGroup, error) {
+	var updated UnsubscribeGroup
+	if _, err := s.client.doJSON(ctx, rest.Patch, groupEndpoint(id), nil, update, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteGroup deletes an unsubscribe group. Addresses suppressed from it
+// are added to the default group, if there is one.
+func (s *ASMService) DeleteGroup(ctx context.Context, id int) error {
+	_, err := s.client.doJSON(ctx, rest.Delete, groupEndpoint(id), nil, nil, nil)
+	return err
+}
+
+// AddGroupSuppressions suppresses emails from a group
+func (s *ASMService) AddGroupSuppressions(ctx context.Context, groupID int, emails ...string) error {
+	_, err := s.client.doJSON(ctx, rest.Post, groupEndpoint(groupID)+"/suppressions", nil, &recipientEmails{emails}, nil)
+	return err
+}
+
+// ListGroupSuppressions lists the addresses suppressed from a group
+func (s *ASMService) ListGroupSuppressions(ctx context.Context, groupID int) ([]string, error) {
+	var emails []string
+	if _, err := s.client.doJSON(ctx, rest.Get, groupEndpoint(groupID)+"/suppressions", nil, nil, &emails); err != nil {
+		return nil, err
+	}
+	return emails, nil
+}
+
+// SearchGroupSuppressions returns which of emails are suppressed from a
+// group
+func (s *ASMService) SearchGroupSuppressions(ctx context.Context, groupID int, emails ...string) ([]string, error) {
+	var suppressed []string
+	if _, err := s.client.doJSON(ctx, rest.Post, groupEndpoint(groupID)+"/suppressions/search", nil, &recipientEmails{emails}, &suppressed); err != nil {
+		return nil, err
+	}
+	return suppressed, nil
+}
+
+// RemoveGroupSuppression lets a group send to email again
+func (s *ASMService) RemoveGroupSuppression(ctx context.Context, groupID int, email string) error {
+	_, err := s.client.doJSON(ctx, rest.Delete, groupEndpoint(groupID)+"/suppressions/"+url.PathEscape(email), nil, nil, nil)
+	return err
+}
+
+// ListSuppressions lists the addresses suppressed from every group
+func (s *ASMService) ListSuppressions(ctx context.Context) ([]*GroupSuppression, error) {
+	var suppressions []*GroupSuppression
+	if _, err := s.client.doJSON(ctx, rest.Get, "/v3/asm/suppressions", nil, nil, &suppressions); err != nil {
+		return nil, err
+	}
+	return suppressions, nil
+}
+
+// SuppressedGroups returns the groups email is suppressed from
+func (s *ASMService) SuppressedGroups(ctx context.Context, email string) ([]*UnsubscribeGroup, error) {
+	var lookup struct {
+		Suppressions []struct {
+			UnsubscribeGroup
+			Suppressed bool `json:"suppressed"`
+		} `json:"suppressions"`
+	}
+	if _, err := s.client.doJSON(ctx, rest.Get, "/v3/asm/suppressions/"+url.PathEscape(email), nil, nil, &lookup); err != nil {
+		return nil, err
+	}
+	var groups []*UnsubscribeGroup
+	for i := range lookup.Suppressions {
+		if lookup.Suppressions[i].Suppressed {
+			groups = append(groups, &lookup.Suppressions[i].UnsubscribeGroup)
+		}
+	}
+	return groups, nil
+}
+
+// AddGlobalSuppressions unsubscribes emails from every message
+func (s *ASMService) AddGlobalSuppressions(ctx context.Context, emails ...string) error {
+	_, err := s.client.doJSON(ctx, rest.Post, "/v3/asm/suppressions/global", nil, &recipientEmails{emails}, nil)
+	return err
+}
+
+// IsGloballySuppressed reports whether email unsubscribed from every
+// message
+func (s *ASMService) IsGloballySuppressed(ctx context.Context, email string) (bool, error) {
+	suppression, err := s.client.Suppressions().GlobalUnsubscribes().Get(ctx, email)
+	return suppression != nil, err
+}
+
+// DeleteGlobalSuppression lets every message be sent to email again
+func (s *ASMService) DeleteGlobalSuppression(ctx context.Context, email string) error {
+	_, err := s.client.doJSON(ctx, rest.Delete, globalSuppressionEndpoint(email), nil, nil, nil)
+	return err
+}
+
+func groupEndpoint(id int) string {
+	return "/v3/asm/groups/" + strconv.Itoa(id)
+}
@@ -0,0 +1,299 @@
+package sendgrid
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sendgrid/sendgrid-go/helpers/mail"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeASM emulates the asm endpoints
+type fakeASM struct {
+	*httptest.Server
+	mu         sync.Mutex
+	groups     map[int]*UnsubscribeGroup
+	suppressed map[int]map[string]bool
+	global     map[string]bool
+	nextID     int
+	lastPatch  string
+}
+
+func newFakeASM() *fakeASM {
+	s := &fakeASM{
+		groups:     make(map[int]*UnsubscribeGroup),
+		suppressed: make(map[int]map[string]bool),
+		global:     make(map[string]bool),
+		nextID:     100,
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+func (s *fakeASM) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var in struct {
+		UnsubscribeGroup
+		RecipientEmails []string `json:"recipient_emails"`
+	}
+	body := readBody(r)
+	json.Unmarshal(body, &in)
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/v3/asm/"), "/")
+	route := r.Method + " " + parts[0]
+	for _, part := range parts[1:] {
+		if _, err := strconv.Atoi(part); err == nil {
+			part = "{id}"
+		} else if strings.Contains(part, "@") {
+			part = "{email}"
+		}
+		route += "/" + part
+	}
+	var group *UnsubscribeGroup
+	if len(parts) > 1 && parts[0] == "groups" {
+		id, _ := strconv.Atoi(parts[1])
+		if group = s.groups[id]; group == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+	}
+	email := parts[len(parts)-1]
+
+	switch route {
+	case "POST groups":
+		s.nextID++
+		group := in.UnsubscribeGroup
+		group.ID = s.nextID
+		s.groups[group.ID] = &group
+		s.suppressed[group.ID] = make(map[string]bool)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(group)
+	case "GET groups":
+		groups := []*UnsubscribeGroup{}
+		for _, group := range s.groups {
+			groups = append(groups, group)
+		}
+		sort.Slice(groups, func(i, j int) bool { return groups[i].ID < groups[j].ID })
+		json.NewEncoder(w).Encode(groups)
+	case "GET groups/{id}":
+		group.Unsubscribes = len(s.suppressed[group.ID])
+		json.NewEncoder(w).Encode(group)
+	case "PATCH groups/{id}":
+		s.lastPatch = string(body)
+		json.Unmarshal(body, group)
+		json.NewEncoder(w).Encode(group)
+	case "DELETE groups/{id}":
+		delete(s.groups, group.ID)
+		w.WriteHeader(http.StatusNoContent)
+	case "POST groups/{id}/suppressions":
+		for _, email := range in.RecipientEmails {
+			s.suppressed[group.ID][email] = true
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(in)
+	case "GET groups/{id}/suppressions":
+		json.NewEncoder(w).Encode(sortedKeys(s.suppressed[group.ID]))
+	case "POST groups/{id}/suppressions/search":
+		found := []string{}
+		for _, email := range in.RecipientEmails {
+			if s.suppressed[group.ID][email] {
+				found = append(found, email)
+			}
+		}
+		json.NewEncoder(w).Encode(found)
+	case "DELETE groups/{id}/suppressions/{email}":
+		delete(s.suppressed[group.ID], email)
+		w.WriteHeader(http.StatusNoContent)
+	case "GET suppressions":
+		all := []*GroupSuppression{}
+		for _, group := range s.groups {
+			for _, email := range sortedKeys(s.suppressed[group.ID]) {
+				all = append(all, &GroupSuppression{Email: email, GroupID: group.ID, GroupName: group.Name, CreatedAt: 1600000000})
+			}
+		}
+		json.NewEncoder(w).Encode(all)
+	case "GET suppressions/{email}":
+		var lookup []map[string]interface{}
+		for id := 101; id <= s.nextID; id++ {
+			if group := s.groups[id]; group != nil {
+				lookup = append(lookup, map[string]interface{}{
+					"id": group.ID, "name": group.Name, "description": group.Description,
+					"is_default": group.IsDefault, "suppressed": s.suppressed[id][email],
+				})
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"suppressions": lookup})
+	case "POST suppressions/global":
+		for _, email := range in.RecipientEmails {
+			s.global[email] = true
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(in)
+	case "GET suppressions/global/{email}":
+		if s.global[email] {
+			fmt.Fprintf(w, `{"recipient_email":%q}`, email)
+		} else {
+			fmt.Fprint(w, `{}`)
+		}
+	case "DELETE suppressions/global/{email}":
+		delete(s.global, email)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := []string{}
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestASM_groups(t *testing.T) {
+	fakeServer := newFakeASM()
+	defer fakeServer.Close()
+	asm := New("SENDGRID_APIKEY", WithHost(fakeServer.URL)).ASM()
+	ctx := context.Background()
+
+	newsletter, err := asm.CreateGroup(ctx, &UnsubscribeGroup{Name: "Newsletter", Description: "Weekly news", IsDefault: true})
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, &UnsubscribeGroup{ID: 101, Name: "Newsletter", Description: "Weekly news", IsDefault: true}, newsletter)
+	offers, err := asm.CreateGroup(ctx, &UnsubscribeGroup{Name: "Offers", Description: "Special offers"})
+	assert.Nil(t, err)
+
+	groups, err := asm.ListGroups(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, []*UnsubscribeGroup{newsletter, offers}, groups)
+
+	updated, err := asm.UpdateGroup(ctx, offers.ID, (&GroupUpdate{}).SetDescription("Seasonal offers"))
+	assert.Nil(t, err)
+	assert.Equal(t, `{"description":"Seasonal offers"}`, fakeServer.lastPatch, "Only the fields set should be sent")
+	assert.Equal(t, "Seasonal offers", updated.Description)
+	assert.Equal(t, offers.Name, updated.Name)
+	assert.Equal(t, offers.IsDefault, updated.IsDefault)
+
+	updated, err = asm.UpdateGroup(ctx, offers.ID, (&GroupUpdate{}).SetName("Deals").SetIsDefault(false))
+	assert.Nil(t, err)
+	assert.Equal(t, `{"name":"Deals","is_default":false}`, fakeServer.lastPatch)
+	assert.Equal(t, "Deals", updated.Name)
+	assert.Equal(t, "Seasonal offers", updated.Description)
+
+	assert.Nil(t, asm.AddGroupSuppressions(ctx, offers.ID, "a@example.com", "b@example.com"))
+	group, err := asm.GetGroup(ctx, offers.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, group.Unsubscribes)
+
+	assert.Nil(t, asm.DeleteGroup(ctx, newsletter.ID))
+	_, err = asm.GetGroup(ctx, newsletter.ID)
+	assert.True(t, IsNotFound(err))
+	assert.True(t, IsNotFound(asm.DeleteGroup(ctx, newsletter.ID)))
+	_, err = asm.UpdateGroup(ctx, newsletter.ID, (&GroupUpdate{}).SetName("Newsletter"))
+	assert.True(t, IsNotFound(err))
+}
+
+func TestASM_suppressions(t *testing.T) {
+	fakeServer := newFakeASM()
+	defer fakeServer.Close()
+	asm := New("SENDGRID_APIKEY", WithHost(fakeServer.URL)).ASM()
+	ctx := context.Background()
+
+	newsletter, _ := asm.CreateGroup(ctx, &UnsubscribeGroup{Name: "Newsletter"})
+	offers, _ := asm.CreateGroup(ctx, &UnsubscribeGroup{Name: "Offers"})
+	assert.Nil(t, asm.AddGroupSuppressions(ctx, newsletter.ID, "a@example.com", "b@example.com"))
+	assert.Nil(t, asm.AddGroupSuppressions(ctx, offers.ID, "a@example.com"))
+
+	emails, err := asm.ListGroupSuppressions(ctx, newsletter.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a@example.com", "b@example.com"}, emails)
+
+	found, err := asm.SearchGroupSuppressions(ctx, offers.ID, "a@example.com", "b@example.com")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a@example.com"}, found)
+
+	groups, err := asm.SuppressedGroups(ctx, "a@example.com")
+	assert.Nil(t, err)
+	if assert.Equal(t, 2, len(groups)) {
+		assert.Equal(t, "Newsletter", groups[0].Name)
+		assert.Equal(t, offers.ID, groups[1].ID)
+	}
+
+	assert.Nil(t, asm.RemoveGroupSuppression(ctx, newsletter.ID, "a@example.com"))
+	groups, err = asm.SuppressedGroups(ctx, "a@example.com")
+	assert.Nil(t, err)
+	if assert.Equal(t, 1, len(groups)) {
+		assert.Equal(t, "Offers", groups[0].Name)
+	}
+
+	all, err := asm.ListSuppressions(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(all))
+
+	_, err = asm.ListGroupSuppressions(ctx, 1)
+	assert.True(t, IsNotFound(err))
+	_, err = asm.SearchGroupSuppressions(ctx, 1, "a@example.com")
+	assert.True(t, IsNotFound(err))
+	assert.True(t, IsNotFound(asm.AddGroupSuppressions(ctx, 1, "a@example.com")))
+	assert.True(t, IsNotFound(asm.RemoveGroupSuppression(ctx, 1, "a@example.com")))
+}
+
+func TestASM_global(t *testing.T) {
+	fakeServer := newFakeASM()
+	defer fakeServer.Close()
+	asm := New("SENDGRID_APIKEY", WithHost(fakeServer.URL)).ASM()
+	ctx := context.Background()
+
+	assert.Nil(t, asm.AddGlobalSuppressions(ctx, "a@example.com", "b@example.com"))
+	suppressed, err := asm.IsGloballySuppressed(ctx, "a@example.com")
+	assert.Nil(t, err)
+	assert.True(t, suppressed)
+
+	assert.Nil(t, asm.DeleteGlobalSuppression(ctx, "a@example.com"))
+	suppressed, err = asm.IsGloballySuppressed(ctx, "a@example.com")
+	assert.Nil(t, err)
+	assert.False(t, suppressed)
+}
+
+func TestASM_errors(t *testing.T) {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer fakeServer.Close()
+	asm := New("SENDGRID_APIKEY", WithHost(fakeServer.URL)).ASM()
+	ctx := context.Background()
+
+	_, err := asm.CreateGroup(ctx, &UnsubscribeGroup{Name: "Newsletter"})
+	assert.True(t, IsForbidden(err))
+	_, err = asm.ListGroups(ctx)
+	assert.True(t, IsForbidden(err))
+	_, err = asm.ListSuppressions(ctx)
+	assert.True(t, IsForbidden(err))
+	_, err = asm.SuppressedGroups(ctx, "a@example.com")
+	assert.True(t, IsForbidden(err))
+	_, err = asm.IsGloballySuppressed(ctx, "a@example.com")
+	assert.True(t, IsForbidden(err))
+	assert.True(t, IsForbidden(asm.AddGlobalSuppressions(ctx, "a@example.com")))
+}
+
+func TestUnsubscribeGroup_Asm(t *testing.T) {
+	group := &UnsubscribeGroup{ID: 101, Name: "Newsletter"}
+	m := mail.NewV3Mail()
+	m.SetASM(group.Asm())
+	assert.Equal(t, &mail.Asm{GroupID: 101}, m.Asm)
+
+	m.SetASM(group.Asm(101, 102))
+	body := string(mail.GetRequestBody(m))
+	assert.Contains(t, body, `"asm":{"group_id":101,"groups_to_display":[101,102]}`)
+}
@@ -0,0 +1,169 @@
+package sendgrid
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/sendgrid/rest"
+)
+
+var (
+	// ErrSenderClosed is returned when enqueueing on a closed AsyncSender
+	ErrSenderClosed = errors.New("sendgrid: async sender is closed")
+	// ErrQueueFull is returned by TryEnqueue when the queue has no room
+	ErrQueueFull = errors.New("sendgrid: async sender queue is full")
+)
+
+// Result is the outcome of a request sent by an AsyncSender. Exactly one of
+// Response and Err is set.
+type Result struct {
+	Request  rest.Request
+	Response *rest.Response
+	Err      error
+}
+
+// AsyncSender sends requests in the background with a bounded number of
+// workers. Requests wait in a bounded queue; once it is full, Enqueue blocks
+// until a worker frees a slot.
+//
+// Every enqueued request produces one Result. Results must be received
+// while the sender is in use: when the results buffer is full, workers stop
+// and the queue backs up.
+type AsyncSender struct {
+	client  *Client
+	queue   chan rest.Request
+	results chan Result
+
+	// mu guards closing the queue against concurrent Enqueue calls
+	mu        sync.RWMutex
+	closed    bool
+	closing   chan struct{}
+	closeOnce sync.Once
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	done   chan struct{}
+}
+
+// NewAsyncSender starts an AsyncSender with concurrency workers and room for
+// queueSize pending requests. Requests go through client, with its transport
+// and retry policy; a nil client uses MakeRequestRetryWithContext.
+func NewAsyncSender(client *Client, concurrency, queueSize int) *AsyncSender {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if queueSize < 0 {
+		queueSize = 0
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &AsyncSender{
+		client:  client,
+		queue:   make(chan rest.Request, queueSize),
+		results: make(chan Result, queueSize+concurrency),
+		closing: make(chan struct{}),
+		ctx:     ctx,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+
+	s.wg.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go s.work()
+	}
+	go func() {
+		s.wg.Wait()
+		close(s.results)
+		cancel()
+		close(s.done)
+	}()
+	return s
+}
+
+// work sends queued requests until the queue is closed
+func (s *AsyncSender) work() {
+	defer s.wg.Done()
+	for request := range s.queue {
+		var response *rest.Response
+		var err error
+		if s.client != nil {
+			response, err = s.client.MakeRequestWithContext(s.ctx, request)
+		} else {
+			response, err = MakeRequestRetryWithContext(s.ctx, request)
+		}
+		if err != nil {
+			response = nil
+		}
+		s.results <- Result{Request: request, Response: response, Err: err}
+	}
+}
+
+// Results returns the channel results are delivered on. It is closed once
+// the sender is closed and every queued request has been sent.
+func (s *AsyncSender) Results() <-chan Result {
+	return s.results
+}
+
+// Enqueue adds a request to the queue, blocking while the queue is full
+// until a slot frees up, ctx is done or the sender is closed.
+func (s *AsyncSender) Enqueue(ctx context.Context, request rest.Request) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return ErrSenderClosed
+	}
+
+	select {
+	case s.queue <- request:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-s.closing:
+		return ErrSenderClosed
+	}
+}
+
+// TryEnqueue adds a request to the queue without blocking, returning
+// ErrQueueFull when there is no room.
+func (s *AsyncSender) TryEnqueue(request rest.Request) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return ErrSenderClosed
+	}
+
+	select {
+	case s.queue <- request:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Close stops accepting requests. Requests already queued are still sent,
+// after which the results channel is closed. Close does not wait.
+func (s *AsyncSender) Close() {
+	s.closeOnce.Do(func() {
+		close(s.closing)
+		s.mu.Lock()
+		s.closed = true
+		close(s.queue)
+		s.mu.Unlock()
+	})
+}
+
+// Drain closes the sender and waits for every queued request to be sent.
+// If ctx is done first, in-flight and pending requests are cancelled, their
+// results carrying the cancellation error, and ctx.Err() is returned.
+func (s *AsyncSender) Drain(ctx context.Context) error {
+	s.Close()
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		s.cancel()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,128 @@
+package sendgrid
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sendgrid/rest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAsyncSender_concurrencyLimit(t *testing.T) {
+	var inFlight, maxInFlight int32
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond * 20)
+		atomic.AddInt32(&inFlight, -1)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer fakeServer.Close()
+	client := New("SENDGRID_APIKEY", WithHost(fakeServer.URL))
+	sender := NewAsyncSender(client, 3, 2)
+
+	done := make(chan int)
+	go func() {
+		count := 0
+		for result := range sender.Results() {
+			assert.Nil(t, result.Err)
+			assert.Equal(t, http.StatusAccepted, result.Response.StatusCode)
+			assert.Equal(t, client.BaseURL, result.Request.BaseURL)
+			count++
+		}
+		done <- count
+	}()
+
+	for i := 0; i < 12; i++ {
+		assert.Nil(t, sender.Enqueue(context.Background(), client.Request))
+	}
+	assert.Nil(t, sender.Drain(context.Background()))
+	assert.Equal(t, 12, <-done, "Every request should produce a result")
+	assert.True(t, atomic.LoadInt32(&maxInFlight) <= 3, "The concurrency limit was exceeded")
+}
+
+func TestAsyncSender_backpressure(t *testing.T) {
+	release := make(chan struct{})
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer fakeServer.Close()
+	client := New("SENDGRID_APIKEY", WithHost(fakeServer.URL))
+	sender := NewAsyncSender(client, 1, 1)
+	request := client.GetRequest("/v3/test_endpoint")
+	request.Method = "GET"
+
+	assert.Nil(t, sender.Enqueue(context.Background(), request))
+	// Wait for the worker to pick up the first request
+	time.Sleep(time.Millisecond * 50)
+	assert.Nil(t, sender.TryEnqueue(request))
+	assert.Equal(t, ErrQueueFull, sender.TryEnqueue(request))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*20)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, sender.Enqueue(ctx, request), "Enqueue should block while the queue is full")
+
+	close(release)
+	sender.Close()
+	assert.Equal(t, ErrSenderClosed, sender.Enqueue(context.Background(), request))
+	assert.Equal(t, ErrSenderClosed, sender.TryEnqueue(request))
+	count := 0
+	for range sender.Results() {
+		count++
+	}
+	assert.Equal(t, 2, count, "Queued requests should be sent after Close")
+}
+
+func TestAsyncSender_drainCancelled(t *testing.T) {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(10 * time.Second):
+		}
+	}))
+	defer fakeServer.Close()
+	client := New("SENDGRID_APIKEY", WithHost(fakeServer.URL))
+	sender := NewAsyncSender(client, 1, 4)
+	request := client.GetRequest("/v3/test_endpoint")
+	request.Method = "GET"
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, sender.Enqueue(context.Background(), request))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, sender.Drain(ctx))
+
+	count := 0
+	for result := range sender.Results() {
+		assert.NotNil(t, result.Err, "Cancelled requests should report an error")
+		assert.Nil(t, result.Response)
+		count++
+	}
+	assert.Equal(t, 3, count)
+}
+
+func TestAsyncSender_nilClient(t *testing.T) {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer fakeServer.Close()
+	sender := NewAsyncSender(nil, 0, -1)
+	request := GetRequest("SENDGRID_APIKEY", "/v3/test_endpoint", fakeServer.URL)
+	request.Method = rest.Get
+	assert.Nil(t, sender.Enqueue(context.Background(), request))
+	result := <-sender.Results()
+	assert.Nil(t, result.Err)
+	assert.Equal(t, http.StatusOK, result.Response.StatusCode)
+	assert.Nil(t, sender.Drain(context.Background()))
+}
@@ -0,0 +1,173 @@
+package sendgrid
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/sendgrid/sendgrid-go/helpers/mail"
+)
+
+// ErrTooManyRecipients is the error of a BulkResult holding a single
+// personalization with more than mail.MaxRecipients recipients, which is
+// not sent as no mail/send request can carry it
+var ErrTooManyRecipients = errors.New("sendgrid: personalization has more recipients than a mail/send request allows")
+
+// BulkResult is the outcome of one mail/send request of a BulkSend
+type BulkResult struct {
+	// Chunk is the index of the request, in recipient order
+	Chunk            int
+	Personalizations []*mail.Personalization
+	// Result is nil if no response was received
+	Result *SendResult
+	// Err is set for non-2xx responses too, as an *APIError
+	Err error
+}
+
+// BulkSend sends base to every personalization read from recipients,
+// until the channel is closed. Personalizations are grouped into requests
+// within the mail/send limits of mail.MaxPersonalizations personalizations
+// and mail.MaxRecipients recipients, sent by up to concurrency goroutines
+// with the client's retry policy. Personalizations of base are ignored.
+// A personalization with more than mail.MaxRecipients recipients is not
+// sent; its result holds it alone, with ErrTooManyRecipients.
+//
+// Every request shares the batch ID of base, or a new batch ID if base has
+// none, so the whole send can be paused or cancelled at once.
+//
+// The results are sorted by chunk. An error is returned if the batch ID
+// could not be created, or if ctx is done before recipients is closed; the
+// results then only cover the recipients read so far, and the producer
+// must stop sending on recipients.
+func (cl *Client) BulkSend(ctx context.Context, base *mail.SGMailV3, recipients <-chan *mail.Personalization, concurrency int) ([]*BulkResult, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	batchID := base.BatchID
+	if batchID == "" {
+		var err error
+		if batchID, err = cl.CreateBatchID(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	chunks := make(chan *BulkResult)
+	var results []*BulkResult
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for chunk := range chunks {
+				cl.sendChunk(ctx, base, batchID, chunk)
+				mu.Lock()
+				results = append(results, chunk)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	err := splitRecipients(ctx, recipients, func(chunk *BulkResult) bool {
+		select {
+		case chunks <- chunk:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	})
+	close(chunks)
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Chunk < results[j].Chunk
+	})
+	return results, err
+}
+
+// splitRecipients groups the personalizations read from recipients into
+// chunks passed to emit, stopping when emit returns false
+func splitRecipients(ctx context.Context, recipients <-chan *mail.Personalization, emit func(*BulkResult) bool) error {
+	chunk := &BulkResult{}
+	count := 0
+	flush := func() bool {
+		if len(chunk.Personalizations) == 0 {
+			return true
+		}
+		if !emit(chunk) {
+			return false
+		}
+		chunk = &BulkResult{Chunk: chunk.Chunk + 1}
+		count = 0
+		return true
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case p, ok := <-recipients:
+			if !ok {
+				if !flush() {
+					return ctx.Err()
+				}
+				return nil
+			}
+			if p == nil {
+				continue
+			}
+			n := len(p.To) + len(p.CC) + len(p.BCC)
+			if n > mail.MaxRecipients {
+				if !flush() {
+					return ctx.Err()
+				}
+				chunk.Personalizations = []*mail.Personalization{p}
+				chunk.Err = ErrTooManyRecipients
+				if !flush() {
+					return ctx.Err()
+				}
+				continue
+			}
+			if len(chunk.Personalizations) == mail.MaxPersonalizations || count+n > mail.MaxRecipients {
+				if !flush() {
+					return ctx.Err()
+				}
+			}
+			chunk.Personalizations = append(chunk.Personalizations, p)
+			count += n
+		}
+	}
+}
+
+// sendChunk sends base to the personalizations of chunk and records the
+// outcome in it. The request is built from a copy of cl.Request, so chunks
+// can be sent concurrently. Chunks rejected while splitting are not sent.
+func (cl *Client) sendChunk(ctx context.Context, base *mail.SGMailV3, batchID string, chunk *BulkResult) {
+	if chunk.Err != nil {
+		return
+	}
+	email := *base
+	email.Personalizations = chunk.Personalizations
+	email.BatchID = batchID
+	if cl.ValidateMail {
+		if chunk.Err = email.Validate(); chunk.Err != nil {
+			return
+		}
+	}
+	body, err := mail.GetRequestBodyE(&email)
+	if err != nil {
+		chunk.Err = err
+		return
+	}
+	request := cl.Request
+	request.Body = body
+	response, err := cl.MakeRequestWithContext(ctx, request)
+	if response != nil {
+		chunk.Result = NewSendResult(response)
+		if err == nil {
+			err = CheckResponse(response)
+		}
+	}
+	chunk.Err = err
+}
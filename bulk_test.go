@@ -0,0 +1,211 @@
+package sendgrid
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/sendgrid/sendgrid-go/helpers/mail"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeBulkServer accepts mail/send requests and records their bodies
+type fakeBulkServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	batches int
+	sent    []*mail.SGMailV3
+}
+
+func newFakeBulkServer(reject func(*mail.SGMailV3) bool) *fakeBulkServer {
+	s := &fakeBulkServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if r.URL.Path == "/v3/mail/batch" {
+			s.batches++
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"batch_id":"YOUR_BATCH_ID"}`)
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		m, err := mail.ParseRequestBody(b)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if reject != nil && reject(m) {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"errors":[{"message":"rejected","field":"personalizations"}]}`)
+			return
+		}
+		s.sent = append(s.sent, m)
+		w.Header().Set("X-Message-Id", fmt.Sprintf("message-%d", len(s.sent)))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	return s
+}
+
+// streamPersonalizations sends n personalizations with a To recipient and
+// cc CC recipients each
+func streamPersonalizations(n, cc int) <-chan *mail.Personalization {
+	recipients := make(chan *mail.Personalization)
+	go func() {
+		defer close(recipients)
+		for i := 0; i < n; i++ {
+			p := mail.NewPersonalization()
+			p.AddTos(mail.NewEmail("", fmt.Sprintf("user%d@example.com", i)))
+			for j := 0; j < cc; j++ {
+				p.AddCCs(mail.NewEmail("", fmt.Sprintf("cc%d.%d@example.com", i, j)))
+			}
+			p.SetSubstitution("-index-", fmt.Sprint(i))
+			recipients <- p
+		}
+	}()
+	return recipients
+}
+
+func TestBulkSend(t *testing.T) {
+	server := newFakeBulkServer(nil)
+	defer server.Close()
+	client := New("SENDGRID_APIKEY", WithHost(server.URL))
+
+	results, err := client.BulkSend(context.Background(), testEmail(), streamPersonalizations(2500, 0), 3)
+	assert.Nil(t, err)
+	if !assert.Equal(t, 3, len(results)) {
+		return
+	}
+	assert.Equal(t, 1, server.batches)
+	for i, expected := range []int{1000, 1000, 500} {
+		assert.Equal(t, i, results[i].Chunk)
+		assert.Equal(t, expected, len(results[i].Personalizations))
+		assert.Nil(t, results[i].Err)
+		assert.NotEqual(t, "", results[i].Result.MessageID)
+	}
+	assert.Equal(t, "0", results[0].Personalizations[0].Substitutions["-index-"])
+	assert.Equal(t, "2499", results[2].Personalizations[499].Substitutions["-index-"])
+	for _, m := range server.sent {
+		assert.Equal(t, "YOUR_BATCH_ID", m.BatchID)
+		assert.Equal(t, "subject", m.Subject)
+	}
+}
+
+func TestBulkSend_recipientLimit(t *testing.T) {
+	server := newFakeBulkServer(nil)
+	defer server.Close()
+	client := New("SENDGRID_APIKEY", WithHost(server.URL))
+	base := testEmail().SetBatchID("EXISTING_BATCH_ID")
+
+	results, err := client.BulkSend(context.Background(), base, streamPersonalizations(1200, 2), 2)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, server.batches, "The batch ID of the base message should be used")
+	if assert.Equal(t, 4, len(results)) {
+		assert.Equal(t, 333, len(results[0].Personalizations))
+		assert.Equal(t, 201, len(results[3].Personalizations))
+	}
+	for _, m := range server.sent {
+		assert.Equal(t, "EXISTING_BATCH_ID", m.BatchID)
+	}
+}
+
+func TestBulkSend_chunkErrors(t *testing.T) {
+	server := newFakeBulkServer(func(m *mail.SGMailV3) bool {
+		return m.Personalizations[0].To[0].Address == "user1000@example.com"
+	})
+	defer server.Close()
+	client := New("SENDGRID_APIKEY", WithHost(server.URL))
+
+	results, err := client.BulkSend(context.Background(), testEmail(), streamPersonalizations(2001, 0), 1)
+	assert.Nil(t, err)
+	if assert.Equal(t, 3, len(results)) {
+		assert.Nil(t, results[0].Err)
+		assert.True(t, IsBadRequest(results[1].Err))
+		assert.Equal(t, "rejected", results[1].Result.Errors[0].Message)
+		assert.Nil(t, results[2].Err)
+		assert.Equal(t, 1, len(results[2].Personalizations))
+	}
+}
+
+func TestBulkSend_cancelled(t *testing.T) {
+	server := newFakeBulkServer(nil)
+	defer server.Close()
+	client := New("SENDGRID_APIKEY", WithHost(server.URL))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	recipients := make(chan *mail.Personalization)
+	go func() {
+		p := mail.NewPersonalization()
+		p.AddTos(mail.NewEmail("", "user@example.com"))
+		recipients <- p
+		cancel()
+	}()
+	results, err := client.BulkSend(ctx, testEmail().SetBatchID("BATCH_ID"), recipients, 1)
+	assert.Equal(t, context.Canceled, err)
+	assert.True(t, len(results) <= 1)
+}
+
+func TestBulkSend_batchError(t *testing.T) {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]interface{}{"errors": []map[string]string{{"message": "authorization required"}}})
+	}))
+	defer fakeServer.Close()
+	client := New("SENDGRID_APIKEY", WithHost(fakeServer.URL))
+
+	results, err := client.BulkSend(context.Background(), testEmail(), streamPersonalizations(1, 0), 1)
+	assert.True(t, IsUnauthorized(err))
+	assert.Nil(t, results)
+}
+
+func TestBulkSend_tooManyRecipients(t *testing.T) {
+	server := newFakeBulkServer(nil)
+	defer server.Close()
+	client := New("SENDGRID_APIKEY", WithHost(server.URL))
+
+	recipients := make(chan *mail.Personalization)
+	go func() {
+		defer close(recipients)
+		for i := 0; i < 3; i++ {
+			p := mail.NewPersonalization()
+			p.AddTos(mail.NewEmail("", fmt.Sprintf("user%d@example.com", i)))
+			if i == 1 {
+				for j := 0; j < mail.MaxRecipients; j++ {
+					p.AddCCs(mail.NewEmail("", fmt.Sprintf("cc%d@example.com", j)))
+				}
+			}
+			recipients <- p
+		}
+	}()
+	results, err := client.BulkSend(context.Background(), testEmail(), recipients, 1)
+	assert.Nil(t, err)
+	if assert.Equal(t, 3, len(results)) {
+		assert.Nil(t, results[0].Err)
+		assert.Equal(t, ErrTooManyRecipients, results[1].Err)
+		assert.Nil(t, results[1].Result, "The personalization should not be sent")
+		assert.Equal(t, mail.MaxRecipients, len(results[1].Personalizations[0].CC))
+		assert.Nil(t, results[2].Err)
+	}
+	assert.Equal(t, 2, len(server.sent))
+}
+
+func TestBulkSend_sendClientBaseURL(t *testing.T) {
+	server := newFakeBulkServer(nil)
+	defer server.Close()
+	client := NewSendClient("SENDGRID_APIKEY")
+	client.Request.BaseURL = server.URL + "/v3/mail/send"
+
+	results, err := client.BulkSend(context.Background(), testEmail(), streamPersonalizations(1, 0), 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, server.batches, "The batch should be created on the host of the sends")
+	if assert.Equal(t, 1, len(results)) {
+		assert.Nil(t, results[0].Err)
+	}
+	for _, m := range server.sent {
+		assert.Equal(t, "YOUR_BATCH_ID", m.BatchID)
+	}
+}
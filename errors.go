@@ -0,0 +1,155 @@
+package sendgrid
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/sendgrid/rest"
+)
+
+// requestIDHeader is the response header SendGrid uses to identify a request
+const requestIDHeader = "X-Request-Id"
+
+// headerValue returns the first value of the named header. Responses built
+// by net/http use canonical keys, but rest.Response.Headers is a plain map and
+// may hold any spelling, such as X-Request-ID, so other keys are matched
+// case-insensitively.
+func headerValue(headers map[string][]string, name string) string {
+	if v := http.Header(headers).Get(name); v != "" {
+		return v
+	}
+	for key, values := range headers {
+		if strings.EqualFold(key, name) && len(values) > 0 {
+			return values[0]
+		}
+	}
+	return ""
+}
+
+// ErrorDetail is a single entry of the errors list SendGrid returns with a
+// failed request
+type ErrorDetail struct {
+	Message string      `json:"message"`
+	Field   string      `json:"field,omitempty"`
+	Help    interface{} `json:"help,omitempty"`
+}
+
+// APIError describes a non-2xx response from the Twilio SendGrid API
+type APIError struct {
+	StatusCode int
+	RequestID  string
+	Errors     []ErrorDetail
+	// RequiredScopes lists the scopes the endpoint needs when the API
+	// answered 403 Forbidden to a request made by a service of the client
+	RequiredScopes Scopes
+	Response       *rest.Response
+
+	// err is a sentinel such as ErrRateLimitRetryExceeded telling why the
+	// response was given up on, if any
+	err error
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("sendgrid: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if e.err != nil {
+		msg += ": " + e.err.Error()
+	}
+	if len(e.Errors) == 0 {
+		return msg
+	}
+	details := make([]string, 0, len(e.Errors))
+	for _, d := range e.Errors {
+		if d.Field != "" {
+			details = append(details, d.Field+": "+d.Message)
+		} else {
+			details = append(details, d.Message)
+		}
+	}
+	return msg + ": " + strings.Join(details, "; ")
+}
+
+// Unwrap returns the sentinel wrapped by the error, so errors.Is(err,
+// ErrRateLimitRetryExceeded) holds for a request that stayed rate limited
+func (e *APIError) Unwrap() error {
+	return e.err
+}
+
+// withRequiredScopes sets the scopes request needs on err if it is a 403
+// *APIError
+func withRequiredScopes(err error, request rest.Request) error {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusForbidden {
+		apiErr.RequiredScopes = requestScopes(request)
+	}
+	return err
+}
+
+// CheckResponse returns an *APIError if the response has a non-2xx status
+// code, and nil otherwise. The errors list in the body is decoded when
+// present; a body that is not in SendGrid's error format is left in
+// APIError.Response.
+func CheckResponse(response *rest.Response) error {
+	if response == nil || (response.StatusCode >= 200 && response.StatusCode < 300) {
+		return nil
+	}
+
+	apiErr := &APIError{
+		StatusCode: response.StatusCode,
+		RequestID:  headerValue(response.Headers, requestIDHeader),
+		Response:   response,
+	}
+
+	var body struct {
+		Errors []ErrorDetail `json:"errors"`
+	}
+	if err := json.Unmarshal([]byte(response.Body), &body); err == nil {
+		apiErr.Errors = body.Errors
+	}
+	return apiErr
+}
+
+// hasStatus reports whether err is an *APIError with the given status code
+func hasStatus(err error, statusCode int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == statusCode
+}
+
+// IsBadRequest reports whether err is an *APIError for a 400 response
+func IsBadRequest(err error) bool {
+	return hasStatus(err, http.StatusBadRequest)
+}
+
+// IsUnauthorized reports whether err is an *APIError for a 401 response
+func IsUnauthorized(err error) bool {
+	return hasStatus(err, http.StatusUnauthorized)
+}
+
+// IsForbidden reports whether err is an *APIError for a 403 response
+func IsForbidden(err error) bool {
+	return hasStatus(err, http.StatusForbidden)
+}
+
+// IsNotFound reports whether err is an *APIError for a 404 response
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsPayloadTooLarge reports whether err is an *APIError for a 413 response
+func IsPayloadTooLarge(err error) bool {
+	return hasStatus(err, http.StatusRequestEntityTooLarge)
+}
+
+// IsRateLimited reports whether err is an *APIError for a 429 response
+func IsRateLimited(err error) bool {
+	return hasStatus(err, http.StatusTooManyRequests)
+}
+
+// IsServerError reports whether err is an *APIError for a 5xx response
+func IsServerError(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode >= 500
+}
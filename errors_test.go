@@ -0,0 +1,98 @@
+package sendgrid
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sendgrid/rest"
+	"github.com/sendgrid/sendgrid-go/helpers/mail"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckResponse_success(t *testing.T) {
+	for _, code := range []int{200, 201, 202, 204} {
+		assert.Nil(t, CheckResponse(&rest.Response{StatusCode: code}), fmt.Sprintf("%d should not be an error", code))
+	}
+	assert.Nil(t, CheckResponse(nil), "A nil response should not be an error")
+}
+
+func TestCheckResponse_decodesErrors(t *testing.T) {
+	response := &rest.Response{
+		StatusCode: http.StatusBadRequest,
+		Body:       `{"errors":[{"message":"The from email does not contain a valid address.","field":"from.email","help":"http://sendgrid.com/docs"}]}`,
+		Headers:    map[string][]string{"X-Request-Id": {"abc123"}},
+	}
+	err := CheckResponse(response)
+
+	var apiErr *APIError
+	if assert.True(t, errors.As(err, &apiErr), "Expected an *APIError") {
+		assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+		assert.Equal(t, "abc123", apiErr.RequestID)
+		assert.Equal(t, 1, len(apiErr.Errors))
+		assert.Equal(t, "from.email", apiErr.Errors[0].Field)
+		assert.Equal(t, "http://sendgrid.com/docs", apiErr.Errors[0].Help)
+		assert.Equal(t, response, apiErr.Response)
+	}
+	assert.Equal(t, "sendgrid: 400 Bad Request: from.email: The from email does not contain a valid address.", err.Error())
+	assert.True(t, IsBadRequest(err))
+	assert.False(t, IsUnauthorized(err))
+}
+
+func TestCheckResponse_requestIDKey(t *testing.T) {
+	for _, key := range []string{"X-Request-Id", "X-Request-ID", "x-request-id"} {
+		err := CheckResponse(&rest.Response{StatusCode: http.StatusNotFound, Headers: map[string][]string{key: {"abc123"}}})
+		var apiErr *APIError
+		if assert.True(t, errors.As(err, &apiErr), "Expected an *APIError") {
+			assert.Equal(t, "abc123", apiErr.RequestID, key)
+		}
+	}
+}
+
+func TestCheckResponse_unparsableBody(t *testing.T) {
+	err := CheckResponse(&rest.Response{StatusCode: http.StatusBadGateway, Body: "<html>Bad Gateway</html>"})
+	assert.NotNil(t, err)
+	assert.Equal(t, "sendgrid: 502 Bad Gateway", err.Error())
+	assert.True(t, IsServerError(err))
+}
+
+func TestAPIErrorHelpers(t *testing.T) {
+	checks := map[int]func(error) bool{
+		http.StatusUnauthorized:          IsUnauthorized,
+		http.StatusForbidden:             IsForbidden,
+		http.StatusNotFound:              IsNotFound,
+		http.StatusRequestEntityTooLarge: IsPayloadTooLarge,
+		http.StatusTooManyRequests:       IsRateLimited,
+	}
+	for code, check := range checks {
+		err := fmt.Errorf("wrapped: %w", CheckResponse(&rest.Response{StatusCode: code}))
+		assert.True(t, check(err), fmt.Sprintf("%d was not detected through a wrapped error", code))
+		assert.False(t, IsServerError(err), fmt.Sprintf("%d is not a server error", code))
+	}
+	assert.False(t, IsRateLimited(errors.New("some error")))
+	assert.False(t, IsRateLimited(nil))
+}
+
+func TestSend_returnAPIErrors(t *testing.T) {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"errors":[{"message":"The provided authorization grant is invalid, expired, or revoked","field":null,"help":null}]}`)
+	}))
+	defer fakeServer.Close()
+	from := mail.NewEmail("Example User", "test@example.com")
+	to := mail.NewEmail("Example User", "test@example.com")
+	email := mail.NewSingleEmail(from, "subject", to, "text", "<p>html</p>")
+
+	client := NewSendClient("SENDGRID_APIKEY")
+	client.BaseURL = fakeServer.URL + "/v3/mail/send"
+	response, err := client.Send(email)
+	assert.Nil(t, err, "Errors should only be returned when opted in")
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+
+	client.ReturnAPIErrors = true
+	response, err = client.Send(email)
+	assert.True(t, IsUnauthorized(err), "Expected an unauthorized error")
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode, "The response should still be returned")
+}
@@ -0,0 +1,69 @@
+package inbound
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// windows1252 maps the bytes 0x80 to 0x9F of Windows-1252 to runes. The
+// other bytes map to the same code points as in ISO-8859-1.
+var windows1252 = [32]rune{
+	'€', '\u0081', '‚', 'ƒ', '„', '…', '†', '‡',
+	'ˆ', '‰', 'Š', '‹', 'Œ', '\u008d', 'Ž', '\u008f',
+	'\u0090', '‘', '’', '“', '”', '•', '–', '—',
+	'˜', '™', 'š', '›', 'œ', '\u009d', 'ž', 'Ÿ',
+}
+
+// charsetReader returns a reader converting input from charset to UTF-8.
+// UTF-8, US-ASCII, ISO-8859-1 and Windows-1252 are converted here, other
+// charsets by the CharsetReader of the parser.
+func (p *Parser) charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(strings.TrimSpace(charset)) {
+	case "", "utf-8", "utf8", "us-ascii", "ascii":
+		return input, nil
+	case "iso-8859-1", "iso8859-1", "iso_8859-1", "latin1", "l1":
+		return singleByteReader(input, false)
+	case "windows-1252", "cp1252", "x-cp1252":
+		return singleByteReader(input, true)
+	}
+	if p.CharsetReader != nil {
+		return p.CharsetReader(charset, input)
+	}
+	return nil, fmt.Errorf("inbound: unsupported charset %q", charset)
+}
+
+// toUTF8 converts b from charset to UTF-8. Text in an unsupported charset
+// is returned unchanged.
+func (p *Parser) toUTF8(charset string, b []byte) []byte {
+	r, err := p.charsetReader(charset, bytes.NewReader(b))
+	if err != nil {
+		return b
+	}
+	converted, err := ioutil.ReadAll(r)
+	if err != nil {
+		return b
+	}
+	return converted
+}
+
+// singleByteReader converts ISO-8859-1, or Windows-1252 if cp1252 is set,
+// to UTF-8
+func singleByteReader(input io.Reader, cp1252 bool) (io.Reader, error) {
+	b, err := ioutil.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	out.Grow(len(b))
+	for _, c := range b {
+		r := rune(c)
+		if cp1252 && c >= 0x80 && c < 0xa0 {
+			r = windows1252[c-0x80]
+		}
+		out.WriteRune(r)
+	}
+	return &out, nil
+}
@@ -0,0 +1,27 @@
+package inbound
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToUTF8(t *testing.T) {
+	p := &Parser{}
+	tests := []struct {
+		charset  string
+		in       string
+		expected string
+	}{
+		{"utf-8", "Größe", "Größe"},
+		{"", "plain", "plain"},
+		{"US-ASCII", "plain", "plain"},
+		{"ISO-8859-1", "caf\xe9 \x80", "café \u0080"},
+		{"latin1", "ma\xf1ana", "mañana"},
+		{"Windows-1252", "\x93quoted\x94 \x80 \x81", "“quoted” € \u0081"},
+		{"x-unknown", "caf\xe9", "caf\xe9"},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, string(p.toUTF8(test.charset, []byte(test.in))), test.charset)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/sendgrid/rest"
+	"github.com/sendgrid/sendgrid-go/helpers/inbound"
+)
+
+type configuration struct {
+	Endpoint string `json:"endpoint"`
+	Port     string `json:"port"`
+}
+
+func loadConfig(path string) (configuration, error) {
+	var conf configuration
+	file, err := ioutil.ReadFile(path)
+	if err != nil {
+		return conf, fmt.Errorf("config file missing: %v", err)
+	}
+	if err := json.Unmarshal(file, &conf); err != nil {
+		return conf, fmt.Errorf("config parse error: %v", err)
+	}
+	return conf, nil
+}
+
+func indexHandler(response http.ResponseWriter, request *http.Request) {
+	fmt.Fprintf(response, "%s", "Hello World")
+}
+
+// inboundHandler prints the emails it receives
+var inboundHandler = inbound.Handler(func(ctx context.Context, email *inbound.ParsedEmail) error {
+	printEmail(email)
+	return nil
+})
+
+func printEmail(email *inbound.ParsedEmail) {
+	fmt.Println("From:", email.From)
+	fmt.Println("To:", email.To)
+	fmt.Println("CC:", email.CC)
+	fmt.Println("Subject:", email.Subject)
+	fmt.Println("Envelope:", email.Envelope.From, "->", email.Envelope.To)
+	fmt.Println("SPF:", email.SPF, "DKIM:", email.DKIM)
+	for key, values := range email.Headers {
+		for _, value := range values {
+			fmt.Println("Header:", key, "=", value)
+		}
+	}
+	fmt.Println("Text:", email.Text)
+	fmt.Println("HTML:", email.HTML)
+	for _, a := range email.Attachments {
+		fmt.Println("Attachment:", a.Filename, a.ContentType, len(a.Content), "bytes")
+	}
+}
+
+func main() {
+	if len(os.Args) > 2 {
+		// Test Sender
+		path := os.Args[1]
+		host := os.Args[2]
+		file, err := ioutil.ReadFile(path)
+		if err != nil {
+			log.Fatal("Check your Filepath. ", err)
+		}
+		Headers := map[string]string{
+			"User-Agent":   "Twilio-SendGrid-Test",
+			"Content-Type": "multipart/form-data; boundary=xYzZY",
+		}
+		method := rest.Post
+		request := rest.Request{
+			Method:  method,
+			BaseURL: host,
+			Headers: Headers,
+			Body:    file,
+		}
+		_, err = rest.Send(request)
+		if err != nil {
+			log.Fatal("Check your Filepath. ", err)
+		}
+	} else {
+		conf, err := loadConfig("./conf.json")
+		if err != nil {
+			log.Fatal(err)
+		}
+		http.HandleFunc("/", indexHandler)
+		http.Handle(conf.Endpoint, inboundHandler)
+		port := os.Getenv("PORT")
+		if port == "" {
+			port = conf.Port
+		}
+		if err := http.ListenAndServe(port, nil); err != nil {
+			log.Fatalln("Error")
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadConfig(t *testing.T) {
+	conf, err := loadConfig("./conf.json")
+	assert.Nil(t, err)
+	assert.NotEqual(t, "", conf.Endpoint, "conf.json did not load correctly, Endpoint empty")
+	assert.NotEqual(t, "", conf.Port, "conf.json did not load correctly, Port empty")
+
+	_, err = loadConfig("./missing.json")
+	assert.NotNil(t, err)
+}
+
+func TestInboundHandler(t *testing.T) {
+	file, err := os.Open("../../sample_data/default_data.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	request := httptest.NewRequest("POST", "/inbound", file)
+	request.Header.Set("Content-Type", "multipart/form-data; boundary=xYzZY")
+	response := httptest.NewRecorder()
+	inboundHandler.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	request = httptest.NewRequest("POST", "/inbound", nil)
+	response = httptest.NewRecorder()
+	inboundHandler.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusBadRequest, response.Code, "Malformed requests should not stop the server")
+}
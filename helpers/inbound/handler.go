@@ -0,0 +1,51 @@
+package inbound
+
+import (
+	"context"
+	"net/http"
+)
+
+// Handler returns an http.Handler parsing Inbound Parse webhook requests
+// with the default parser, see (*Parser).Handler
+func Handler(callback func(context.Context, *ParsedEmail) error) http.Handler {
+	return defaultParser.Handler(callback)
+}
+
+// Handler returns an http.Handler parsing Inbound Parse webhook requests
+// and passing each email to callback with the context of the request.
+// Requests over MaxBodySize get a 413, and attachments spooled by
+// SpoolToDir are removed once callback returns.
+func (p *Parser) Handler(callback func(context.Context, *ParsedEmail) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		email, err := p.ParseRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), parseErrorStatus(err))
+			return
+		}
+		defer email.Cleanup()
+
+		if err := callback(r.Context(), email); err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+// parseErrorStatus is the status code of responses to requests that fail
+// to parse
+func parseErrorStatus(err error) int {
+	if err == ErrBodyTooLarge {
+		return http.StatusRequestEntityTooLarge
+	}
+	if _, ok := err.(*spoolError); ok {
+		return http.StatusInternalServerError
+	}
+	return http.StatusBadRequest
+}
@@ -0,0 +1,70 @@
+package inbound
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler(t *testing.T) {
+	var received *ParsedEmail
+	handler := Handler(func(ctx context.Context, email *ParsedEmail) error {
+		assert.NotNil(t, ctx)
+		received = email
+		return nil
+	})
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, sampleRequest(t, "./sample_data/default_data.txt"))
+	assert.Equal(t, http.StatusOK, response.Code)
+	if assert.NotNil(t, received) {
+		assert.Equal(t, "Testing non-raw", received.Subject)
+	}
+}
+
+func TestHandler_callbackError(t *testing.T) {
+	handler := Handler(func(ctx context.Context, email *ParsedEmail) error {
+		return errors.New("database down")
+	})
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, sampleRequest(t, "./sample_data/default_data.txt"))
+	assert.Equal(t, http.StatusInternalServerError, response.Code, "SendGrid should retry emails the callback failed on")
+}
+
+func TestHandler_errors(t *testing.T) {
+	called := false
+	p := &Parser{MaxBodySize: 1024}
+	handler := p.Handler(func(ctx context.Context, email *ParsedEmail) error {
+		called = true
+		return nil
+	})
+
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequest("GET", "/inbound", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, response.Code)
+	assert.Equal(t, "POST", response.Header().Get("Allow"))
+
+	response = httptest.NewRecorder()
+	request := httptest.NewRequest("POST", "/inbound", strings.NewReader("{}"))
+	request.Header.Set("Content-Type", "application/json")
+	handler.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+
+	response = httptest.NewRecorder()
+	handler.ServeHTTP(response, sampleRequest(t, "./sample_data/default_data_with_attachments.txt"))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, response.Code)
+
+	p.Spool = func(a *Attachment) (io.WriteCloser, error) {
+		return nil, errors.New("disk full")
+	}
+	p.MaxBodySize = -1
+	response = httptest.NewRecorder()
+	handler.ServeHTTP(response, sampleRequest(t, "./sample_data/default_data_with_attachments.txt"))
+	assert.Equal(t, http.StatusInternalServerError, response.Code)
+	assert.False(t, called)
+}
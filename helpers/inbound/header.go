@@ -0,0 +1,46 @@
+package inbound
+
+import (
+	"net/textproto"
+	"strings"
+)
+
+// parseHeaders parses a block of header lines. Folded lines are unfolded,
+// every value of repeated headers such as Received is kept and
+// encoded-words are decoded. Lines that are not headers are skipped.
+func (p *Parser) parseHeaders(block string) textproto.MIMEHeader {
+	headers := make(textproto.MIMEHeader)
+	var key, value string
+	add := func() {
+		if key != "" {
+			headers.Add(key, p.decodeWords(strings.TrimSpace(value)))
+		}
+		key, value = "", ""
+	}
+
+	for _, line := range strings.Split(block, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if line == "" {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			if key != "" {
+				value += line
+			}
+			continue
+		}
+		add()
+		i := strings.Index(line, ":")
+		if i < 0 {
+			continue
+		}
+		// obsolete syntax allows whitespace before the colon
+		name := strings.TrimRight(line[:i], " \t")
+		if name == "" || strings.ContainsAny(name, " \t") {
+			continue
+		}
+		key, value = textproto.CanonicalMIMEHeaderKey(name), line[i+1:]
+	}
+	add()
+	return headers
+}
@@ -0,0 +1,45 @@
+package inbound
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseHeaders(t *testing.T) {
+	block := "Received: from a.example.com\n" +
+		"\tby b.example.com; Tue, 03 Mar 2020 10:00:00 +0000\n" +
+		"received: from c.example.com by a.example.com\r\n" +
+		"Subject: =?utf-8?q?Caf=C3=A9?= =?utf-8?q?_menu?=\n" +
+		"X-Note: a: b: c\n" +
+		"Comments : obsolete syntax\n" +
+		"not a header\n" +
+		" orphaned continuation\n" +
+		"Bad Name: skipped\n" +
+		"X-Broken: =?x-unknown?q?kept?=\n" +
+		"\n"
+	headers := (&Parser{}).parseHeaders(block)
+	assert.Equal(t, []string{
+		"from a.example.com\tby b.example.com; Tue, 03 Mar 2020 10:00:00 +0000",
+		"from c.example.com by a.example.com",
+	}, headers["Received"])
+	assert.Equal(t, "Café menu", headers.Get("Subject"))
+	assert.Equal(t, "a: b: c", headers.Get("X-Note"))
+	assert.Equal(t, "obsolete syntax", headers.Get("Comments"))
+	assert.Equal(t, "=?x-unknown?q?kept?=", headers.Get("X-Broken"))
+	assert.Equal(t, 5, len(headers))
+
+	assert.Equal(t, 0, len((&Parser{}).parseHeaders("")))
+}
+
+func TestParseHeaders_raw(t *testing.T) {
+	email := parseSample(t, &Parser{}, "outlook_related.eml")
+	received := email.Headers.Values("Received")
+	if assert.Equal(t, 2, len(received)) {
+		assert.Equal(t, "from mx.sendgrid.net (mx.sendgrid.net [192.0.2.10])\tby parse.sendgrid.net with ESMTP id def456; Tue, 03 Mar 2020 10:00:01 +0000 (UTC)", received[0])
+		assert.Equal(t, "from mail.example.com (mail.example.com [192.0.2.1])\tby mx.sendgrid.net with ESMTP id abc123\tfor <inbound@inbound.example.com>; Tue, 03 Mar 2020 10:00:00 +0000 (UTC)", received[1])
+	}
+	assert.Equal(t, "v=1; a=rsa-sha256; c=relaxed/relaxed; d=example.com;\ts=selector1; h=From:Subject:Date:Message-ID;\tbh=frcCV1k9oG9oKj3dpUqdJg1PxRT2RSN/XKdLCPjaYaY=; b=dGVzdA==", email.Headers.Get("Dkim-Signature"))
+	assert.Equal(t, "Café – menu", email.Headers.Get("Subject"))
+	assert.Equal(t, "Renée Dupré <renee@example.com>", email.Headers.Get("From"))
+}
@@ -1,218 +1,338 @@
-package main
+// Package inbound parses the requests the Twilio SendGrid Inbound Parse
+// webhook posts for each received email, in both the default and the raw
+// ("send raw") modes.
+package inbound
 
 import (
-	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
-	"log"
 	"mime"
 	"mime/multipart"
 	"net/http"
-	"os"
+	"net/mail"
+	"net/textproto"
+	"strconv"
 	"strings"
-
-	"github.com/sendgrid/rest"
 )
 
-type configuration struct {
-	Endpoint string `json:"endpoint"`
-	Port     string `json:"port"`
+// ParsedEmail is an email received through the Inbound Parse webhook
+type ParsedEmail struct {
+	From    *mail.Address
+	To      []*mail.Address
+	CC      []*mail.Address
+	Subject string
+	Text    string
+	HTML    string
+	// Headers are the headers of the email, with encoded-words decoded
+	Headers  textproto.MIMEHeader
+	Envelope Envelope
+	SenderIP string
+	// SPF and DKIM are the verification results, e.g. "pass" and
+	// "{@example.com : pass}"
+	SPF  string
+	DKIM string
+	// SpamScore and SpamReport are only set when spam checking is enabled
+	SpamScore  float64
+	SpamReport string
+	// Charsets maps form fields to the charset they were received in
+	Charsets    map[string]string
+	Attachments []*Attachment
+	// RawEmail is the full MIME message, only set in raw mode
+	RawEmail []byte
 }
 
-func loadConfig(path string) configuration {
-	file, err := ioutil.ReadFile(path)
-	if err != nil {
-		log.Fatal("Config File Missing. ", err)
-	}
-	var conf configuration
-	err = json.Unmarshal(file, &conf)
-	if err != nil {
-		log.Fatal("Config Parse Error: ", err)
-	}
-	return conf
+// Envelope holds the SMTP envelope of an email
+type Envelope struct {
+	From string   `json:"from"`
+	To   []string `json:"to"`
 }
 
-func indexHandler(response http.ResponseWriter, request *http.Request) {
-	_, err := fmt.Fprintf(response, "%s", "Hello World")
-	if err != nil {
-		log.Fatal(err)
-	}
+// Attachment is a file attached to an email
+type Attachment struct {
+	Filename    string
+	ContentType string
+	// ContentID is set for inline attachments referenced from the HTML
+	ContentID string
+	Size      int64
+	// Content is nil when the attachment was spooled, see Parser.Spool
+	Content []byte
+	// Path is the file the attachment was spooled to by SpoolToDir
+	Path string
+}
+
+// attachmentInfo describes an attachment in the attachment-info field
+type attachmentInfo struct {
+	Filename  string `json:"filename"`
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	ContentID string `json:"content-id"`
+}
+
+// Parser parses Inbound Parse webhook requests. The zero value is ready to
+// use.
+type Parser struct {
+	// CharsetReader converts text from charsets other than UTF-8, US-ASCII,
+	// ISO-8859-1 and Windows-1252 to UTF-8, e.g. charset.NewReaderLabel of
+	// golang.org/x/net/html/charset. Text in other charsets is kept as is.
+	CharsetReader func(charset string, input io.Reader) (io.Reader, error)
+	// MaxBodySize limits the size of requests, DefaultMaxBodySize if zero.
+	// Use a negative value for no limit.
+	MaxBodySize int64
+	// Spool, if set, receives the content of attachments instead of
+	// keeping it in memory
+	Spool SpoolFunc
 }
 
-func getBoundary(value string, contentType string) (string, *strings.Reader) {
-	body := strings.NewReader(value)
-	bodySplit := strings.Split(string(value), contentType)
-	scanner := bufio.NewScanner(strings.NewReader(bodySplit[1]))
-	var lines []string
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
-		break
-	}
-	boundary := lines[0][9:]
-	return boundary, body
+// DefaultMaxBodySize leaves room for the form encoding over the 30MB limit
+// of messages received by Inbound Parse
+const DefaultMaxBodySize = 40 << 20
+
+// ErrBodyTooLarge is returned for requests larger than the MaxBodySize of
+// the parser
+var ErrBodyTooLarge = errors.New("inbound: request body too large")
+
+// defaultParser is used by ParseRequest
+var defaultParser = &Parser{}
+
+// ParseRequest parses an Inbound Parse webhook request with the default
+// Parser
+func ParseRequest(request *http.Request) (*ParsedEmail, error) {
+	return defaultParser.ParseRequest(request)
 }
 
-func inboundHandler(response http.ResponseWriter, request *http.Request) {
+// ParseRequest parses an Inbound Parse webhook request. It returns an error
+// when the request is not multipart/form-data or holds malformed fields.
+// Text fields are converted to UTF-8 from the charsets SendGrid reports.
+//
+// Attachments spooled by SpoolToDir must be removed with Cleanup.
+func (p *Parser) ParseRequest(request *http.Request) (*ParsedEmail, error) {
 	mediaType, params, err := mime.ParseMediaType(request.Header.Get("Content-Type"))
 	if err != nil {
-		log.Fatal(err)
-	}
-	if strings.HasPrefix(mediaType, "multipart/") {
-		mr := multipart.NewReader(request.Body, params["boundary"])
-		parsedEmail := make(map[string]string)
-		emailHeader := make(map[string]string)
-		binaryFiles := make(map[string][]byte)
-		parsedRawEmail := make(map[string]string)
-		rawFiles := make(map[string]string)
-		for {
-			p, err := mr.NextPart()
-			// We have found an attachment with binary data
-			if err == nil && p.FileName() != "" {
-				contents, ioerr := ioutil.ReadAll(p)
-				if ioerr != nil {
-					log.Fatal(err)
-				}
-				binaryFiles[p.FileName()] = contents
-			}
-			if err == io.EOF {
-				// We have finished parsing, do something with the parsed data
-				printMap(parsedEmail, "")
-				printMap(emailHeader, "e")
-
-				for key, value := range binaryFiles {
-					fmt.Println("bKey:", key, " bValue:", value)
-				}
-
-				printMap(parsedRawEmail, "r")
-				printMap(rawFiles, "rf")
-				// Twilio SendGrid needs a 200 OK response to stop POSTing
-				response.WriteHeader(http.StatusOK)
-				return
-			}
-			if err != nil {
-				log.Fatal(err)
-			}
-			value, err := ioutil.ReadAll(p)
-			if err != nil {
-				log.Fatal(err)
-			}
-			header := p.Header.Get("Content-Disposition")
-			if !strings.Contains(header, "filename") {
-				header = header[17 : len(header)-1]
-				parsedEmail[header] = string(value)
-			} else {
-				header = header[11:]
-				f := strings.Split(header, "=")
-				parsedEmail[f[1][1:len(f[1])-11]] = f[2][1 : len(f[2])-1]
-			}
-
-			if header == "headers" {
-				handleHeaders(value, emailHeader)
-			}
-			// Since we have parsed the headers, we can delete the original
-			delete(parsedEmail, "headers")
+		return nil, fmt.Errorf("inbound: invalid content type: %v", err)
+	}
+	if mediaType != "multipart/form-data" || params["boundary"] == "" {
+		return nil, fmt.Errorf("inbound: unexpected content type %q", mediaType)
+	}
 
-			// We have a raw message
-			if header == "email" {
-				handleRawEmail(value, parsedRawEmail, rawFiles)
-			}
-			// Since we've parsed this header, we can delete the original
-			delete(parsedEmail, "email")
+	max := p.MaxBodySize
+	if max == 0 {
+		max = DefaultMaxBodySize
+	}
+	body := &limitedReader{r: request.Body, n: max}
+	email, err := p.parseForm(multipart.NewReader(body, params["boundary"]))
+	if body.exceeded {
+		if email != nil {
+			email.Cleanup()
 		}
+		return nil, ErrBodyTooLarge
 	}
+	return email, err
 }
-func handleHeaders(value []byte, emailHeader map[string]string) {
-	s := strings.Split(string(value), "\n")
-	var a []string
-	for _, v := range s {
-		t := strings.Split(string(v), ": ")
-		a = append(a, t...)
-	}
-	for i := 0; i < len(a)-1; i += 2 {
-		emailHeader[a[i]] = a[i+1]
-	}
+
+// limitedReader fails once more than n bytes are read, unless n is
+// negative
+type limitedReader struct {
+	r        io.Reader
+	n        int64
+	exceeded bool
 }
 
-func printMap(inputMap map[string]string, prefix string) {
-	for key, value := range inputMap {
-		fmt.Println(prefix, "Key:", key, " ", prefix, "Value:", value)
+func (l *limitedReader) Read(b []byte) (int, error) {
+	if l.n < 0 {
+		return l.r.Read(b)
+	}
+	if l.n == 0 {
+		var probe [1]byte
+		if n, _ := l.r.Read(probe[:]); n > 0 {
+			l.exceeded = true
+			return 0, ErrBodyTooLarge
+		}
+		return 0, io.EOF
 	}
+	if int64(len(b)) > l.n {
+		b = b[:l.n]
+	}
+	n, err := l.r.Read(b)
+	l.n -= int64(n)
+	return n, err
 }
-func handleRawEmail(value []byte, parsedRawEmail map[string]string, rawFiles map[string]string) {
-	boundary, body := getBoundary(string(value), "Content-Type: multipart/mixed; ")
-	raw := multipart.NewReader(body, boundary)
+
+// parseForm reads the form fields and files posted by the webhook. Spooled
+// attachments are removed if it fails.
+func (p *Parser) parseForm(mr *multipart.Reader) (email *ParsedEmail, err error) {
+	fields := make(map[string]string)
+	files := make(map[string]*Attachment)
+	var order []string
+	defer func() {
+		if err != nil {
+			removeSpooled(files)
+			if email != nil {
+				email.Cleanup()
+			}
+			email = nil
+		}
+	}()
+
 	for {
-		next, err := raw.NextPart()
+		part, err := mr.NextPart()
 		if err == io.EOF {
-			// We have finished parsing
 			break
 		}
-		value, err := ioutil.ReadAll(next)
 		if err != nil {
-			log.Fatal(err)
+			return nil, fmt.Errorf("inbound: invalid form: %v", err)
 		}
-		header := next.Header.Get("Content-Type")
-
-		// Parse the headers
-		if strings.Contains(header, "multipart/alternative") {
-			boundary, body := getBoundary(string(value), "Content-Type: multipart/alternative; ")
-			raw := multipart.NewReader(body, boundary)
-			for {
-				next, err := raw.NextPart()
-				if err == io.EOF {
-					// We have finished parsing
-					break
-				}
-				value, err = ioutil.ReadAll(next)
-				if err != nil {
-					log.Fatal(err)
-				}
-				header = next.Header.Get("Content-Type")
-				parsedRawEmail[header] = string(value)
+		if part.FileName() != "" {
+			a := &Attachment{
+				Filename:    part.FileName(),
+				ContentType: part.Header.Get("Content-Type"),
+			}
+			files[part.FormName()] = a
+			order = append(order, part.FormName())
+			if err := p.readContent(a, part); err != nil {
+				return nil, readError(part.FormName(), err)
 			}
-		} else {
-			// It's a base64 encoded attachment
-			rawFiles[header] = string(value)
+			continue
 		}
-	}
-}
-
-func main() {
-	if len(os.Args) > 1 {
-		// Test Sender
-		path := os.Args[1]
-		host := os.Args[2]
-		file, err := ioutil.ReadFile(path)
+		value, err := ioutil.ReadAll(part)
 		if err != nil {
-			log.Fatal("Check your Filepath. ", err)
+			return nil, readError(part.FormName(), err)
 		}
-		Headers := map[string]string{
-			"User-Agent":   "Twilio-SendGrid-Test",
-			"Content-Type": "multipart/form-data; boundary=xYzZY",
-		}
-		method := rest.Post
-		request := rest.Request{
-			Method:  method,
-			BaseURL: host,
-			Headers: Headers,
-			Body:    file,
+		fields[part.FormName()] = string(value)
+	}
+
+	var charsets map[string]string
+	if err := unmarshalField(fields, "charsets", &charsets); err != nil {
+		return nil, err
+	}
+	for name, charset := range charsets {
+		if value, ok := fields[name]; ok {
+			fields[name] = string(p.toUTF8(charset, []byte(value)))
 		}
-		_, err = rest.Send(request)
+	}
+
+	email = &ParsedEmail{
+		Charsets:   charsets,
+		Subject:    fields["subject"],
+		Text:       fields["text"],
+		HTML:       fields["html"],
+		SenderIP:   fields["sender_ip"],
+		SPF:        fields["SPF"],
+		DKIM:       fields["dkim"],
+		SpamReport: fields["spam_report"],
+	}
+	email.From = p.parseAddress(fields["from"])
+	email.To = p.parseAddressList(fields["to"])
+	email.CC = p.parseAddressList(fields["cc"])
+
+	if err := unmarshalField(fields, "envelope", &email.Envelope); err != nil {
+		return nil, err
+	}
+	if score := fields["spam_score"]; score != "" {
+		f, err := strconv.ParseFloat(score, 64)
 		if err != nil {
-			log.Fatal("Check your Filepath. ", err)
+			return nil, fmt.Errorf("inbound: invalid spam_score %q", score)
+		}
+		email.SpamScore = f
+	}
+
+	if raw, ok := fields["email"]; ok {
+		email.RawEmail = []byte(raw)
+		if err := p.parseRawEmail(email); err != nil {
+			return email, err
 		}
-	} else {
-		conf := loadConfig("./conf.json")
-		http.HandleFunc("/", indexHandler)
-		http.HandleFunc(conf.Endpoint, inboundHandler)
-		port := os.Getenv("PORT")
-		if port == "" {
-			port = conf.Port
+		return email, nil
+	}
+
+	email.Headers = p.parseHeaders(fields["headers"])
+	if err := attachFiles(email, fields, files, order); err != nil {
+		return nil, err
+	}
+	return email, nil
+}
+
+// attachFiles adds the posted files to email, completed with the details
+// of the attachment-info and content-ids fields
+func attachFiles(email *ParsedEmail, fields map[string]string, files map[string]*Attachment, order []string) error {
+	var info map[string]attachmentInfo
+	if err := unmarshalField(fields, "attachment-info", &info); err != nil {
+		return err
+	}
+	var contentIDs map[string]string
+	if err := unmarshalField(fields, "content-ids", &contentIDs); err != nil {
+		return err
+	}
+	for cid, name := range contentIDs {
+		if a, ok := info[name]; ok && a.ContentID == "" {
+			a.ContentID = cid
+			info[name] = a
+		} else if !ok {
+			if info == nil {
+				info = make(map[string]attachmentInfo)
+			}
+			info[name] = attachmentInfo{ContentID: cid}
 		}
-		if err := http.ListenAndServe(port, nil); err != nil {
-			log.Fatalln("Error")
+	}
+
+	for _, name := range order {
+		a := files[name]
+		if i, ok := info[name]; ok {
+			if i.Filename != "" {
+				a.Filename = i.Filename
+			}
+			if i.Type != "" {
+				a.ContentType = i.Type
+			}
+			a.ContentID = i.ContentID
 		}
+		email.Attachments = append(email.Attachments, a)
+	}
+	return nil
+}
+
+// unmarshalField decodes a JSON form field, if present
+func unmarshalField(fields map[string]string, name string, v interface{}) error {
+	value := strings.TrimSpace(fields[name])
+	if value == "" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(value), v); err != nil {
+		return fmt.Errorf("inbound: invalid %s: %v", name, err)
+	}
+	return nil
+}
+
+// addressParser decodes encoded-words with the charsets of the parser
+func (p *Parser) addressParser() *mail.AddressParser {
+	return &mail.AddressParser{WordDecoder: &mime.WordDecoder{CharsetReader: p.charsetReader}}
+}
+
+// parseAddress parses an address, keeping it verbatim in Address when it
+// is malformed
+func (p *Parser) parseAddress(s string) *mail.Address {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	a, err := p.addressParser().Parse(s)
+	if err != nil {
+		return &mail.Address{Address: s}
+	}
+	return a
+}
+
+// parseAddressList parses a list of addresses, keeping the list verbatim
+// in a single Address when it is malformed
+func (p *Parser) parseAddressList(s string) []*mail.Address {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	list, err := p.addressParser().ParseList(s)
+	if err != nil {
+		return []*mail.Address{{Address: s}}
 	}
+	return list
 }
@@ -1,25 +1,117 @@
-package main
+package inbound
 
 import (
+	"bytes"
 	"io/ioutil"
-	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
-func TestLoadConfig(t *testing.T) {
-	conf := loadConfig("./conf.json")
-	assert.NotNil(t, conf.Endpoint, "conf.json did not load correctly, Endpoint empty")
-	assert.NotNil(t, conf.Port, "conf.json did not load correctly, Port empty")
+func sampleRequest(t *testing.T, path string) *http.Request {
+	file, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := httptest.NewRequest("POST", "/inbound", bytes.NewReader(file))
+	request.Header.Set("Content-Type", "multipart/form-data; boundary=xYzZY")
+	return request
 }
 
-func TestGetBoundary(t *testing.T) {
-	file, _ := ioutil.ReadFile("./sample_data/raw_data.txt")
-	boundary, body := getBoundary(string(file), "Content-Type: multipart/alternative; ")
-	raw := multipart.NewReader(body, boundary)
-	next, _ := raw.NextPart()
-	value, _ := ioutil.ReadAll(next)
-	assert.Equal(t, "001a113ee97c89842f0539be8e7a", boundary, "The boundary was not found.")
-	assert.Equal(t, "Hello Twilio SendGrid!\n", string(value), "The email was not parsed properly.")
+func TestParseRequest_default(t *testing.T) {
+	email, err := ParseRequest(sampleRequest(t, "./sample_data/default_data.txt"))
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, &mail.Address{Name: "Example User", Address: "test@example.com"}, email.From)
+	assert.Equal(t, []*mail.Address{{Address: "inbound@inbound.example.com"}}, email.To)
+	assert.Nil(t, email.CC)
+	assert.Equal(t, "Testing non-raw", email.Subject)
+	assert.Equal(t, "Hello Twilio SendGrid!\n", email.Text)
+	assert.Equal(t, "<html><body><strong>Hello Twilio SendGrid!</body></html>\n", email.HTML)
+	assert.Equal(t, "Inbound Parse Test Data", email.Headers.Get("Subject"))
+	assert.Equal(t, Envelope{From: "test@example.com", To: []string{"inbound@inbound.example.com"}}, email.Envelope)
+	assert.Equal(t, "0.0.0.0", email.SenderIP)
+	assert.Equal(t, "pass", email.SPF)
+	assert.Equal(t, "{@sendgrid.com : pass}", email.DKIM)
+	assert.Equal(t, "UTF-8", email.Charsets["subject"])
+	assert.Nil(t, email.Attachments)
+	assert.Nil(t, email.RawEmail)
+}
+
+func TestParseRequest_defaultWithAttachments(t *testing.T) {
+	email, err := ParseRequest(sampleRequest(t, "./sample_data/default_data_with_attachments.txt"))
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, []*mail.Address{{Name: "Other User", Address: "other@example.com"}}, email.CC)
+	assert.Equal(t, 0.012, email.SpamScore)
+	assert.True(t, strings.HasPrefix(email.SpamReport, "Spam detection software"))
+	if assert.Equal(t, 2, len(email.Attachments)) {
+		assert.Equal(t, &Attachment{Filename: "notes.txt", ContentType: "text/plain", Size: 14, Content: []byte("Meeting notes\n")}, email.Attachments[0])
+		assert.Equal(t, "pixel.png", email.Attachments[1].Filename)
+		assert.Equal(t, "image/png", email.Attachments[1].ContentType)
+		assert.Equal(t, "ii_139db99fdb6c1a2c", email.Attachments[1].ContentID)
+		assert.Equal(t, "\x89PNG", string(email.Attachments[1].Content[:4]))
+	}
+}
+
+func TestParseRequest_raw(t *testing.T) {
+	email, err := ParseRequest(sampleRequest(t, "./sample_data/raw_data.txt"))
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, "Testing with Request.bin", email.Subject)
+	assert.Equal(t, "Hello Twilio SendGrid!\n", email.Text, "The email was not parsed properly.")
+	assert.Equal(t, "<html><body><strong>Hello Twilio SendGrid!</body></html>\n", email.HTML)
+	assert.Equal(t, "Inbound Parse Test Raw Data", email.Headers.Get("Subject"))
+	assert.Equal(t, "multipart/alternative; boundary=001a113ee97c89842f0539be8e7a", email.Headers.Get("Content-Type"))
+	assert.True(t, strings.HasPrefix(string(email.RawEmail), "MIME-Version: 1.0\n"))
+	assert.Nil(t, email.Attachments)
+}
+
+func TestParseRequest_rawWithAttachments(t *testing.T) {
+	email, err := ParseRequest(sampleRequest(t, "./sample_data/raw_data_with_attachments.txt"))
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, "Hello Twilio SendGrid!\n", email.Text)
+	if assert.Equal(t, 1, len(email.Attachments)) {
+		a := email.Attachments[0]
+		assert.Equal(t, "TwilioSendGrid.jpg", a.Filename)
+		assert.Equal(t, `image/jpeg; name="TwilioSendGrid.jpg"`, a.ContentType)
+		assert.Equal(t, "\xff\xd8\xff\xe0", string(a.Content[:4]), "The attachment should be base64 decoded")
+	}
+}
+
+func TestParseRequest_errors(t *testing.T) {
+	tests := map[string]string{
+		"multipart/form-data; boundary=xYzZY": "--xYzZY\nContent-Disposition: form-data; name=\"envelope\"\n\nnot json\n--xYzZY--",
+		"multipart/form-data; boundary=xYz":   "--xYzZY\nContent-Disposition: form-data; name=\"to\"\n\nunterminated",
+		"application/json":                    "{}",
+		"":                                    "",
+	}
+	for contentType, body := range tests {
+		request := httptest.NewRequest("POST", "/inbound", strings.NewReader(body))
+		request.Header.Set("Content-Type", contentType)
+		_, err := ParseRequest(request)
+		assert.NotNil(t, err, contentType)
+	}
+
+	body := "--xYzZY\nContent-Disposition: form-data; name=\"spam_score\"\n\nhigh\n--xYzZY--"
+	request := httptest.NewRequest("POST", "/inbound", strings.NewReader(body))
+	request.Header.Set("Content-Type", "multipart/form-data; boundary=xYzZY")
+	_, err := ParseRequest(request)
+	assert.NotNil(t, err)
+}
+
+func TestParseAddress_malformed(t *testing.T) {
+	p := &Parser{}
+	assert.Equal(t, &mail.Address{Address: "not an address"}, p.parseAddress("not an address"))
+	assert.Nil(t, p.parseAddress(" "))
+	assert.Equal(t, []*mail.Address{{Address: "a@example.com, <broken"}}, p.parseAddressList("a@example.com, <broken"))
 }
@@ -0,0 +1,166 @@
+package inbound
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+)
+
+// maxPartDepth limits the nesting of multipart bodies in raw emails
+const maxPartDepth = 32
+
+// parseRawEmail fills the headers, content and attachments of email from
+// its raw MIME message
+func (p *Parser) parseRawEmail(email *ParsedEmail) error {
+	msg, err := mail.ReadMessage(bytes.NewReader(email.RawEmail))
+	if err != nil {
+		return fmt.Errorf("inbound: invalid raw email: %v", err)
+	}
+	email.Headers = p.parseHeaders(string(headerBlock(email.RawEmail)))
+	return p.walkPart(email, textproto.MIMEHeader(msg.Header), msg.Body, 0)
+}
+
+// headerBlock returns the header section of a raw message, which ends at
+// the first empty line whether lines end in CRLF, LF or a mix of both
+func headerBlock(raw []byte) []byte {
+	for i := 0; i < len(raw); {
+		n := bytes.IndexByte(raw[i:], '\n')
+		if n < 0 {
+			break
+		}
+		if line := raw[i : i+n]; len(line) == 0 || (len(line) == 1 && line[0] == '\r') {
+			return raw[:i]
+		}
+		i += n + 1
+	}
+	return raw
+}
+
+// walkPart adds a part of a raw email to the content or attachments of
+// email, descending into multipart parts. Text parts that are not
+// attachments are decoded to UTF-8 and appended to Text or HTML; every
+// other part is an attachment, inline if it has a Content-ID.
+func (p *Parser) walkPart(email *ParsedEmail, header textproto.MIMEHeader, body io.Reader, depth int) error {
+	if depth > maxPartDepth {
+		return fmt.Errorf("inbound: MIME parts nested more than %d levels deep", maxPartDepth)
+	}
+
+	contentType := header.Get("Content-Type")
+	mediaType, params := "text/plain", map[string]string{"charset": "us-ascii"}
+	if contentType != "" {
+		var err error
+		mediaType, params, err = mime.ParseMediaType(contentType)
+		if mediaType == "" {
+			return fmt.Errorf("inbound: invalid content type %q: %v", contentType, err)
+		}
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		boundary := params["boundary"]
+		if boundary == "" {
+			return fmt.Errorf("inbound: no boundary in %q", contentType)
+		}
+		mr := multipart.NewReader(body, boundary)
+		for {
+			part, err := mr.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("inbound: invalid %s body: %v", mediaType, err)
+			}
+			if err := p.walkPart(email, part.Header, part, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	disposition, dispositionParams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	filename := dispositionParams["filename"]
+	if filename == "" {
+		filename = params["name"]
+	}
+	filename = p.decodeWords(filename)
+	content := decodeTransferEncoding(header.Get("Content-Transfer-Encoding"), body)
+
+	if filename == "" && disposition != "attachment" && (mediaType == "text/plain" || mediaType == "text/html") {
+		text, err := ioutil.ReadAll(content)
+		if err != nil {
+			return fmt.Errorf("inbound: invalid %s part: %v", mediaType, err)
+		}
+		if mediaType == "text/plain" {
+			email.Text += string(p.toUTF8(params["charset"], text))
+		} else {
+			email.HTML += string(p.toUTF8(params["charset"], text))
+		}
+		return nil
+	}
+
+	a := &Attachment{
+		Filename:    filename,
+		ContentType: contentType,
+		ContentID:   strings.Trim(header.Get("Content-Id"), "<> "),
+	}
+	email.Attachments = append(email.Attachments, a)
+	if err := p.readContent(a, content); err != nil {
+		return readError(mediaType+" part", err)
+	}
+	return nil
+}
+
+// decodeTransferEncoding decodes base64 and quoted-printable bodies.
+// Other encodings are identity encodings.
+func decodeTransferEncoding(encoding string, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, &spaceStripper{r: body})
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	}
+	return body
+}
+
+// decodeWords decodes RFC 2047 encoded-words, returning s unchanged if it
+// can not be decoded
+func (p *Parser) decodeWords(s string) string {
+	if !strings.Contains(s, "=?") {
+		return s
+	}
+	dec := &mime.WordDecoder{CharsetReader: p.charsetReader}
+	decoded, err := dec.DecodeHeader(s)
+	if err != nil {
+		return s
+	}
+	return decoded
+}
+
+// spaceStripper drops the whitespace some mailers put in base64 bodies
+type spaceStripper struct {
+	r io.Reader
+}
+
+func (s *spaceStripper) Read(b []byte) (int, error) {
+	for {
+		n, err := s.r.Read(b)
+		kept := 0
+		for _, c := range b[:n] {
+			switch c {
+			case ' ', '\t', '\r', '\n':
+			default:
+				b[kept] = c
+				kept++
+			}
+		}
+		if kept > 0 || err != nil {
+			return kept, err
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package inbound
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// parseSample parses a raw email of the sample corpus
+func parseSample(t *testing.T, p *Parser, name string) *ParsedEmail {
+	raw, err := ioutil.ReadFile("./sample_data/raw/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	email := &ParsedEmail{RawEmail: raw}
+	if err := p.parseRawEmail(email); err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	return email
+}
+
+func TestParseRawEmail_corpus(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		html        string
+		attachments []Attachment
+	}{
+		{
+			name: "outlook_related.eml",
+			text: "Café – today’s menu\r\n",
+			html: `<html><body><p>Café – today’s menu</p><img src="cid:image001.png@01D5F1A0.12345670"></body></html>` + "\r\n",
+			attachments: []Attachment{
+				{Filename: "image001.png", ContentType: `image/png; name="image001.png"`, ContentID: "image001.png@01D5F1A0.12345670"},
+			},
+		},
+		{
+			name: "apple_mail_nested.eml",
+			text: "Here are the photos.\n",
+			html: `<html><body>Here are the photos.<img src="cid:ABC-123"></body></html>`,
+			attachments: []Attachment{
+				{Filename: "pixel.png", ContentType: `image/png; name="pixel.png"`, ContentID: "ABC-123"},
+				{Filename: "résumé.txt", ContentType: `text/plain; x-unix-mode=0644; name="=?utf-8?Q?r=C3=A9sum=C3=A9.txt?="`, Content: []byte("Curriculum vitæ")},
+			},
+		},
+		{
+			name: "single_part_latin1.eml",
+			text: "Nos vemos mañana en el café.\n",
+		},
+		{
+			name: "html_only_utf8.eml",
+			text: "kept as is",
+			html: "<p>Größe: 42 €</p>",
+			attachments: []Attachment{
+				{Filename: "report.csv", ContentType: "text/csv; charset=utf-8", Content: []byte("id,name\n1,café\n")},
+			},
+		},
+	}
+	for _, test := range tests {
+		email := parseSample(t, &Parser{}, test.name)
+		assert.Equal(t, test.text, email.Text, test.name)
+		assert.Equal(t, test.html, email.HTML, test.name)
+		if !assert.Equal(t, len(test.attachments), len(email.Attachments), test.name) {
+			continue
+		}
+		for i, expected := range test.attachments {
+			a := email.Attachments[i]
+			assert.Equal(t, expected.Filename, a.Filename, test.name)
+			assert.Equal(t, expected.ContentType, a.ContentType, test.name)
+			assert.Equal(t, expected.ContentID, a.ContentID, test.name)
+			if expected.Content != nil {
+				assert.Equal(t, string(expected.Content), string(a.Content), test.name)
+			} else {
+				assert.Equal(t, "\x89PNG", string(a.Content[:4]), test.name)
+			}
+		}
+	}
+}
+
+func TestParseRawEmail_charsetReader(t *testing.T) {
+	p := &Parser{CharsetReader: func(charset string, input io.Reader) (io.Reader, error) {
+		if charset != "x-unknown" {
+			return nil, fmt.Errorf("unexpected charset %s", charset)
+		}
+		b, _ := ioutil.ReadAll(input)
+		return strings.NewReader(strings.ToUpper(string(b))), nil
+	}}
+	email := parseSample(t, p, "html_only_utf8.eml")
+	assert.Equal(t, "KEPT AS IS", email.Text)
+}
+
+func TestParseRawEmail_mixedLineEndings(t *testing.T) {
+	tests := map[string]string{
+		"CRLF headers, LF body":  "Subject: Hello\r\nX-Test: 1\r\n\r\nline one\n\nline two\n",
+		"CRLF headers, mixed":    "Subject: Hello\r\nX-Test: 1\r\n\nline one\r\n\r\nline two\n",
+		"LF headers, CRLF blank": "Subject: Hello\nX-Test: 1\n\r\nline one\n\nline two\n",
+	}
+	for name, raw := range tests {
+		email := &ParsedEmail{RawEmail: []byte(raw)}
+		if assert.Nil(t, (&Parser{}).parseRawEmail(email), name) {
+			assert.Equal(t, "Hello", email.Headers.Get("Subject"), name)
+			assert.Equal(t, "1", email.Headers.Get("X-Test"), name)
+			assert.Equal(t, 2, len(email.Headers), name+": the body should not be read as headers")
+			assert.Contains(t, email.Text, "line two", name)
+		}
+	}
+	assert.Equal(t, "", string(headerBlock([]byte("\r\nbody"))))
+	assert.Equal(t, "Subject: Hello", string(headerBlock([]byte("Subject: Hello"))))
+}
+
+func TestParseRawEmail_errors(t *testing.T) {
+	tests := map[string]string{
+		"no header":        "not a message",
+		"no boundary":      "Content-Type: multipart/mixed\n\nbody",
+		"bad content type": "Content-Type: /\n\nbody",
+		"unterminated":     "Content-Type: multipart/mixed; boundary=b\n\n--b\nContent-Type: text/plain\n\ntext",
+		"bad base64":       "Content-Type: image/png\nContent-Transfer-Encoding: base64\n\n!!!!",
+		"too deep":         strings.Repeat("Content-Type: multipart/mixed; boundary=b\n\n--b\n", maxPartDepth+2),
+	}
+	for name, raw := range tests {
+		email := &ParsedEmail{RawEmail: []byte(raw)}
+		assert.NotNil(t, (&Parser{}).parseRawEmail(email), name)
+	}
+}
+
+func TestParseRequest_rawCorpus(t *testing.T) {
+	raw, err := ioutil.ReadFile("./sample_data/raw/outlook_related.eml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	w.WriteField("email", string(raw))
+	w.WriteField("from", "=?Windows-1252?Q?Ren=E9e_Dupr=E9?= <renee@example.com>")
+	w.WriteField("subject", "Caf\xe9 \x96 menu")
+	w.WriteField("charsets", `{"subject":"windows-1252","from":"UTF-8"}`)
+	w.Close()
+	request := httptest.NewRequest("POST", "/inbound", &body)
+	request.Header.Set("Content-Type", w.FormDataContentType())
+
+	email, err := ParseRequest(request)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "Café – menu", email.Subject)
+		assert.Equal(t, "Renée Dupré", email.From.Name)
+		assert.Equal(t, "Café – today’s menu\r\n", email.Text)
+		assert.Equal(t, 1, len(email.Attachments))
+	}
+}
@@ -0,0 +1,112 @@
+package inbound
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// SpoolFunc returns the writer the content of an attachment is copied to.
+// The writer is closed once the attachment is read.
+type SpoolFunc func(a *Attachment) (io.WriteCloser, error)
+
+// SpoolToDir spools attachments to temporary files in dir, or the default
+// directory for temporary files if dir is empty. The path of each file is
+// set in the Path of its attachment.
+func SpoolToDir(dir string) SpoolFunc {
+	return func(a *Attachment) (io.WriteCloser, error) {
+		file, err := ioutil.TempFile(dir, "inbound-")
+		if err != nil {
+			return nil, err
+		}
+		a.Path = file.Name()
+		return file, nil
+	}
+}
+
+// Cleanup removes the files attachments were spooled to by SpoolToDir
+func (e *ParsedEmail) Cleanup() error {
+	var first error
+	for _, a := range e.Attachments {
+		if err := removeFile(a); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// spoolError is a failure to write an attachment to its spool, as opposed
+// to a malformed request
+type spoolError struct {
+	err error
+}
+
+func (e *spoolError) Error() string {
+	return fmt.Sprintf("inbound: spooling attachment: %v", e.err)
+}
+
+func (e *spoolError) Unwrap() error {
+	return e.err
+}
+
+// spoolWriter tells write errors apart from read errors while copying
+type spoolWriter struct {
+	w io.Writer
+}
+
+func (s spoolWriter) Write(b []byte) (int, error) {
+	n, err := s.w.Write(b)
+	if err != nil {
+		err = &spoolError{err}
+	}
+	return n, err
+}
+
+// readContent reads the content of a into memory, or into the spool of
+// the parser if it has one
+func (p *Parser) readContent(a *Attachment, r io.Reader) error {
+	if p.Spool == nil {
+		content, err := ioutil.ReadAll(r)
+		a.Content = content
+		a.Size = int64(len(content))
+		return err
+	}
+
+	w, err := p.Spool(a)
+	if err != nil {
+		return &spoolError{err}
+	}
+	a.Size, err = io.Copy(spoolWriter{w}, r)
+	if closeErr := w.Close(); err == nil && closeErr != nil {
+		err = &spoolError{closeErr}
+	}
+	return err
+}
+
+// readError describes an error reading part, keeping spool errors as
+// they are
+func readError(part string, err error) error {
+	if _, ok := err.(*spoolError); ok {
+		return err
+	}
+	return fmt.Errorf("inbound: reading %s: %v", part, err)
+}
+
+// removeSpooled removes the files of attachments that were spooled before
+// parsing failed
+func removeSpooled(files map[string]*Attachment) {
+	for _, a := range files {
+		removeFile(a)
+	}
+}
+
+func removeFile(a *Attachment) error {
+	if a.Path == "" {
+		return nil
+	}
+	if err := os.Remove(a.Path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package inbound
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+func TestSpoolToDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "inbound-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	p := &Parser{Spool: SpoolToDir(dir)}
+	email, err := p.ParseRequest(sampleRequest(t, "./sample_data/default_data_with_attachments.txt"))
+	if !assert.Nil(t, err) || !assert.Equal(t, 2, len(email.Attachments)) {
+		return
+	}
+	a := email.Attachments[0]
+	assert.Equal(t, "notes.txt", a.Filename)
+	assert.Equal(t, int64(14), a.Size)
+	assert.Nil(t, a.Content)
+	content, err := ioutil.ReadFile(a.Path)
+	assert.Nil(t, err)
+	assert.Equal(t, "Meeting notes\n", string(content))
+
+	assert.Nil(t, email.Cleanup())
+	for _, a := range email.Attachments {
+		_, err := os.Stat(a.Path)
+		assert.True(t, os.IsNotExist(err), "Cleanup should remove %s", a.Path)
+	}
+}
+
+func TestSpool_writer(t *testing.T) {
+	spooled := make(map[string]*bytes.Buffer)
+	p := &Parser{Spool: func(a *Attachment) (io.WriteCloser, error) {
+		buf := new(bytes.Buffer)
+		spooled[a.Filename] = buf
+		return nopCloser{buf}, nil
+	}}
+	email, err := p.ParseRequest(sampleRequest(t, "./sample_data/raw_data_with_attachments.txt"))
+	if !assert.Nil(t, err) || !assert.Equal(t, 1, len(email.Attachments)) {
+		return
+	}
+	assert.Equal(t, "Hello Twilio SendGrid!\n", email.Text, "Text parts should not be spooled")
+	buf := spooled["TwilioSendGrid.jpg"]
+	if assert.NotNil(t, buf) {
+		assert.Equal(t, int64(buf.Len()), email.Attachments[0].Size)
+		assert.Equal(t, "\xff\xd8\xff\xe0", buf.String()[:4])
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(b []byte) (int, error) { return 0, errors.New("disk full") }
+
+func (failingWriter) Close() error { return nil }
+
+func TestSpool_errors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "inbound-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the second attachment fails, the first must not be left behind
+	spool := SpoolToDir(dir)
+	p := &Parser{Spool: func(a *Attachment) (io.WriteCloser, error) {
+		if a.Filename == "pixel.png" {
+			return failingWriter{}, nil
+		}
+		return spool(a)
+	}}
+	_, err = p.ParseRequest(sampleRequest(t, "./sample_data/default_data_with_attachments.txt"))
+	_, ok := err.(*spoolError)
+	assert.True(t, ok, "Expected a spool error, got %v", err)
+	files, _ := ioutil.ReadDir(dir)
+	assert.Equal(t, 0, len(files))
+
+	_, err = SpoolToDir(dir + "/missing")(&Attachment{})
+	assert.NotNil(t, err)
+}
+
+func TestParseRequest_maxBodySize(t *testing.T) {
+	p := &Parser{MaxBodySize: 100}
+	_, err := p.ParseRequest(sampleRequest(t, "./sample_data/default_data.txt"))
+	assert.Equal(t, ErrBodyTooLarge, err)
+
+	p.MaxBodySize = -1
+	_, err = p.ParseRequest(sampleRequest(t, "./sample_data/default_data.txt"))
+	assert.Nil(t, err)
+
+	info, _ := os.Stat("./sample_data/default_data.txt")
+	p.MaxBodySize = info.Size()
+	_, err = p.ParseRequest(sampleRequest(t, "./sample_data/default_data.txt"))
+	assert.Nil(t, err, "Bodies of exactly MaxBodySize should parse")
+}
@@ -0,0 +1,97 @@
+package mail
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MaxMessageSize is the limit on the total size of a message sent through
+// the v3 mail/send endpoint, counted here as the size of its base64 encoded
+// attachments
+const MaxMessageSize = 30 * 1024 * 1024
+
+// maxAttachmentSize is the largest content whose base64 encoding fits in
+// MaxMessageSize
+const maxAttachmentSize = MaxMessageSize / 4 * 3
+
+// ErrAttachmentTooLarge is returned when attachment content does not fit in
+// MaxMessageSize
+var ErrAttachmentTooLarge = errors.New("mail: attachment exceeds the 30MB message size limit")
+
+// sniffLen is the number of bytes http.DetectContentType looks at
+const sniffLen = 512
+
+// NewAttachmentFromReader reads r into a base64 encoded attachment named
+// filename. The type is guessed from the file extension, or from the
+// content when the extension is unknown.
+func NewAttachmentFromReader(filename string, r io.Reader) (*Attachment, error) {
+	a, err := readAttachment(filename, r)
+	if err != nil {
+		return nil, err
+	}
+	return a.SetFilename(filename).SetDisposition("attachment"), nil
+}
+
+// NewAttachmentFromFile reads the file at path into an attachment named
+// after the file
+func NewAttachmentFromFile(path string) (*Attachment, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return NewAttachmentFromReader(filepath.Base(path), f)
+}
+
+// NewAttachmentFromBytes builds an attachment named filename from content
+func NewAttachmentFromBytes(filename string, content []byte) (*Attachment, error) {
+	return NewAttachmentFromReader(filename, bytes.NewReader(content))
+}
+
+// NewInlineAttachment reads r into an inline attachment that HTML content
+// can reference as cid:<cid>, typically an image. The type is guessed from
+// the content.
+func NewInlineAttachment(cid string, r io.Reader) (*Attachment, error) {
+	a, err := readAttachment("", r)
+	if err != nil {
+		return nil, err
+	}
+	return a.SetFilename(cid).SetDisposition("inline").SetContentID(cid), nil
+}
+
+// readAttachment stream encodes r and detects its type
+func readAttachment(filename string, r io.Reader) (*Attachment, error) {
+	br := bufio.NewReaderSize(r, sniffLen)
+	head, err := br.Peek(sniffLen)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	contentType := mime.TypeByExtension(filepath.Ext(filename))
+	if contentType == "" {
+		contentType = http.DetectContentType(head)
+	}
+
+	var content strings.Builder
+	enc := base64.NewEncoder(base64.StdEncoding, &content)
+	n, err := io.Copy(enc, io.LimitReader(br, maxAttachmentSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if n > maxAttachmentSize {
+		return nil, ErrAttachmentTooLarge
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+
+	return NewAttachment().SetContent(content.String()).SetType(contentType), nil
+}
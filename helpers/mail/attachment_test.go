@@ -0,0 +1,101 @@
+package mail
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var pngHeader = []byte("\x89PNG\x0D\x0A\x1A\x0A\x00\x00\x00\x0DIHDR")
+
+func TestNewAttachmentFromReader(t *testing.T) {
+	a, err := NewAttachmentFromReader("report.pdf", strings.NewReader("%PDF-1.4 content"))
+	assert.Nil(t, err)
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("%PDF-1.4 content")), a.Content)
+	assert.Equal(t, "application/pdf", a.Type)
+	assert.Equal(t, "report.pdf", a.Filename)
+	assert.Equal(t, "attachment", a.Disposition)
+	assert.Equal(t, "", a.ContentID)
+}
+
+func TestNewAttachmentFromReader_sniff(t *testing.T) {
+	a, err := NewAttachmentFromReader("logo", bytes.NewReader(pngHeader))
+	assert.Nil(t, err)
+	assert.Equal(t, "image/png", a.Type, "The type should be sniffed without a known extension")
+
+	a, err = NewAttachmentFromReader("empty", strings.NewReader(""))
+	assert.Nil(t, err)
+	assert.Equal(t, "", a.Content)
+	assert.Equal(t, "text/plain; charset=utf-8", a.Type)
+}
+
+func TestNewAttachmentFromReader_large(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 100000)
+	a, err := NewAttachmentFromReader("big.txt", bytes.NewReader(content))
+	assert.Nil(t, err)
+	decoded, err := base64.StdEncoding.DecodeString(a.Content)
+	assert.Nil(t, err)
+	assert.Equal(t, content, decoded)
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+func TestNewAttachmentFromReader_tooLarge(t *testing.T) {
+	_, err := NewAttachmentFromReader("huge.bin", io.LimitReader(zeroReader{}, maxAttachmentSize+1))
+	assert.Equal(t, ErrAttachmentTooLarge, err)
+
+	a, err := NewAttachmentFromReader("max.bin", io.LimitReader(zeroReader{}, maxAttachmentSize))
+	assert.Nil(t, err)
+	assert.Equal(t, MaxMessageSize, len(a.Content))
+}
+
+func TestNewAttachmentFromFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "attachment")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "notes.txt")
+	assert.Nil(t, ioutil.WriteFile(path, []byte("hello"), 0600))
+
+	a, err := NewAttachmentFromFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "aGVsbG8=", a.Content)
+	assert.Equal(t, "notes.txt", a.Filename)
+	assert.True(t, strings.HasPrefix(a.Type, "text/plain"))
+
+	_, err = NewAttachmentFromFile(filepath.Join(dir, "missing.txt"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestNewAttachmentFromBytes(t *testing.T) {
+	a, err := NewAttachmentFromBytes("data.json", []byte(`{"a":1}`))
+	assert.Nil(t, err)
+	assert.Equal(t, "application/json", a.Type)
+	assert.Equal(t, "data.json", a.Filename)
+}
+
+func TestNewInlineAttachment(t *testing.T) {
+	a, err := NewInlineAttachment("logo", bytes.NewReader(pngHeader))
+	assert.Nil(t, err)
+	assert.Equal(t, "image/png", a.Type)
+	assert.Equal(t, "inline", a.Disposition)
+	assert.Equal(t, "logo", a.ContentID)
+	assert.Equal(t, "logo", a.Filename)
+
+	m := validMail()
+	m.AddAttachment(a)
+	assert.Nil(t, m.Validate())
+}
@@ -1,7 +1,10 @@
 package mail
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"io"
 	"log"
 	"net/mail"
 )
@@ -178,14 +181,53 @@ func NewV3MailInit(from *Email, subject string, to *Email, content ...*Content)
 }
 
 // GetRequestBody ...
+// Marshal errors are logged and a nil body returned, use GetRequestBodyE
+// to handle them.
 func GetRequestBody(m *SGMailV3) []byte {
-	b, err := json.Marshal(m)
+	b, err := GetRequestBodyE(m)
 	if err != nil {
 		log.Println(err)
 	}
 	return b
 }
 
+// GetRequestBodyE returns the JSON body of a mail/send request, or the
+// error preventing the message from being marshaled, such as a channel or
+// func in DynamicTemplateData.
+func GetRequestBodyE(m *SGMailV3) ([]byte, error) {
+	return json.Marshal(m)
+}
+
+// ParseRequestBody decodes a mail/send JSON body back into an SGMailV3.
+// Fields the helper does not support are dropped. Numbers in
+// DynamicTemplateData are kept as json.Number, unlike json.Unmarshal which
+// decodes them as float64, so the message marshals back unchanged.
+func ParseRequestBody(b []byte) (*SGMailV3, error) {
+	return parseRequestBody(b, false)
+}
+
+// ParseRequestBodyStrict is like ParseRequestBody, but returns an error
+// for fields the helper does not support instead of dropping them.
+func ParseRequestBodyStrict(b []byte) (*SGMailV3, error) {
+	return parseRequestBody(b, true)
+}
+
+func parseRequestBody(b []byte, strict bool) (*SGMailV3, error) {
+	m := new(SGMailV3)
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	if strict {
+		d.DisallowUnknownFields()
+	}
+	if err := d.Decode(m); err != nil {
+		return nil, err
+	}
+	if _, err := d.Token(); err != io.EOF {
+		return nil, errors.New("mail: unexpected data after the request body")
+	}
+	return m, nil
+}
+
 // AddPersonalizations ...
 func (s *SGMailV3) AddPersonalizations(p ...*Personalization) *SGMailV3 {
 	s.Personalizations = append(s.Personalizations, p...)
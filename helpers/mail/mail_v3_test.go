@@ -1,8 +1,11 @@
 package mail
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"math/rand"
+	"reflect"
 	"testing"
 	"time"
 
@@ -741,3 +744,162 @@ func TestParseInvalidEmail(t *testing.T) {
 		t.Error("Expected an error to be thrown from ParseEmail")
 	}
 }
+
+// newFullMail returns a message with every field the helper supports set
+func newFullMail() *SGMailV3 {
+	m := NewV3Mail()
+	m.SetFrom(NewEmail("From", "from@example.com"))
+	m.SetReplyTo(NewEmail("Reply", "reply@example.com"))
+	m.Subject = "subject"
+	p := NewPersonalization()
+	p.AddTos(NewEmail("To", "to@example.com"))
+	p.AddCCs(NewEmail("CC", "cc@example.com"))
+	p.AddBCCs(NewEmail("BCC", "bcc@example.com"))
+	p.Subject = "personal subject"
+	p.SetHeader("X-Test", "test")
+	p.SetSubstitution("-name-", "Example")
+	p.SetCustomArg("user_id", "343")
+	p.SetDynamicTemplateData("total", 12345678901234567)
+	p.SetDynamicTemplateData("price", 9.99)
+	p.SetDynamicTemplateData("items", []interface{}{
+		map[string]interface{}{"name": "widget", "count": 2, "tags": []string{"a", "b"}},
+	})
+	p.SetDynamicTemplateData("nested", map[string]interface{}{"ok": true, "none": nil})
+	p.Categories = append(p.Categories, "personal")
+	p.SetSendAt(1409348513)
+	m.AddPersonalizations(p)
+	m.AddContent(NewContent("text/plain", "text"), NewContent("text/html", "<p>html</p>"))
+	a := NewAttachment().SetContent("aGVsbG8=").SetType("text/plain").SetFilename("hello.txt").SetDisposition("inline").SetContentID("hello")
+	a.Name = "hello"
+	m.AddAttachment(a)
+	m.SetTemplateID("d-123")
+	m.AddSection("%section%", "section")
+	m.SetHeader("X-Message", "message")
+	m.AddCategories("category")
+	m.SetCustomArg("campaign", "welcome")
+	m.SetSendAt(1409348513)
+	m.SetBatchID("batch")
+	m.SetASM(NewASM().SetGroupID(1).AddGroupsToDisplay(1, 2))
+	m.SetIPPoolID("pool")
+	m.SetMailSettings(NewMailSettings().
+		SetBCC(NewBCCSetting().SetEnable(true).SetEmail("bcc@example.com")).
+		SetBypassListManagement(NewSetting(false)).
+		SetFooter(NewFooterSetting().SetEnable(true).SetText("footer").SetHTML("<p>footer</p>")).
+		SetSandboxMode(NewSetting(true)).
+		SetSpamCheckSettings(NewSpamCheckSetting().SetEnable(true).SetSpamThreshold(5).SetPostToURL("https://example.com")))
+	ts := NewTrackingSettings().
+		SetClickTracking(NewClickTrackingSetting().SetEnable(true).SetEnableText(false)).
+		SetOpenTracking(NewOpenTrackingSetting().SetEnable(true).SetSubstitutionTag("%open%")).
+		SetSubscriptionTracking(NewSubscriptionTrackingSetting().SetEnable(true).SetText("unsub").SetHTML("<p>unsub</p>").SetSubstitutionTag("%unsub%")).
+		SetGoogleAnalytics(NewGaSetting().SetEnable(true).SetCampaignSource("source").SetCampaignTerm("term").SetCampaignContent("content").SetCampaignName("name").SetCampaignMedium("medium"))
+	ts.BCC = NewBCCSetting().SetEnable(false)
+	ts.BypassListManagement = NewSetting(true)
+	ts.Footer = NewFooterSetting().SetEnable(false)
+	ts.SandboxMode = NewSandboxModeSetting(true, false, NewSpamCheckSetting().SetEnable(false))
+	m.SetTrackingSettings(ts)
+	return m
+}
+
+func TestGetRequestBodyE(t *testing.T) {
+	b, err := GetRequestBodyE(newFullMail())
+	assert.Nil(t, err)
+	assert.Contains(t, string(b), `"total":12345678901234567`)
+
+	m := newFullMail()
+	m.Personalizations[0].SetDynamicTemplateData("channel", make(chan int))
+	b, err = GetRequestBodyE(m)
+	assert.NotNil(t, err, "An unsupported value should return an error")
+	assert.Nil(t, b)
+	assert.Nil(t, GetRequestBody(m), "GetRequestBody should keep returning a nil body")
+}
+
+func TestV3JSONRoundTrip(t *testing.T) {
+	m := newFullMail()
+	values := []interface{}{
+		m, m.From, m.Personalizations[0], m.Content[0], m.Attachments[0], m.Asm,
+		m.MailSettings, m.MailSettings.BCC, m.MailSettings.BypassListManagement,
+		m.MailSettings.Footer, m.MailSettings.SpamCheckSetting,
+		m.TrackingSettings, m.TrackingSettings.ClickTracking, m.TrackingSettings.OpenTracking,
+		m.TrackingSettings.SubscriptionTracking, m.TrackingSettings.GoogleAnalytics,
+		m.TrackingSettings.SandboxMode,
+	}
+	for _, v := range values {
+		first, err := json.Marshal(v)
+		assert.Nil(t, err)
+		decoded := reflect.New(reflect.TypeOf(v).Elem()).Interface()
+		// json.Number keeps 12345678901234567, which a float64 would round
+		d := json.NewDecoder(bytes.NewReader(first))
+		d.UseNumber()
+		assert.Nil(t, d.Decode(decoded), fmt.Sprintf("%T should unmarshal", v))
+		second, err := json.Marshal(decoded)
+		assert.Nil(t, err)
+		assert.JSONEq(t, string(first), string(second), fmt.Sprintf("%T did not round-trip", v))
+		assert.Equal(t, string(first), string(second), fmt.Sprintf("%T should marshal back byte for byte", v))
+	}
+}
+
+func TestV3PersonalizationUnmarshalJSON_numbers(t *testing.T) {
+	body := `{"dynamic_template_data":{"id":12345678901234567,"nested":{"n":1.5}}}`
+	var p Personalization
+	assert.Nil(t, json.Unmarshal([]byte(body), &p))
+	assert.Equal(t, float64(12345678901234567), p.DynamicTemplateData["id"], "json.Unmarshal should keep its float64 numbers")
+	assert.Equal(t, 1.5, p.DynamicTemplateData["nested"].(map[string]interface{})["n"])
+
+	m, err := ParseRequestBody([]byte(`{"personalizations":[` + body + `]}`))
+	if assert.Nil(t, err) {
+		data := m.Personalizations[0].DynamicTemplateData
+		assert.Equal(t, json.Number("12345678901234567"), data["id"])
+		assert.Equal(t, json.Number("1.5"), data["nested"].(map[string]interface{})["n"])
+	}
+	_, err = ParseRequestBody([]byte(`{"personalizations":[{"to":"not a list"}]}`))
+	assert.NotNil(t, err)
+}
+
+func TestParseRequestBody(t *testing.T) {
+	body, err := GetRequestBodyE(newFullMail())
+	assert.Nil(t, err)
+
+	for _, parse := range []func([]byte) (*SGMailV3, error){ParseRequestBody, ParseRequestBodyStrict} {
+		m, err := parse(body)
+		if assert.Nil(t, err) {
+			again, err := GetRequestBodyE(m)
+			assert.Nil(t, err)
+			assert.Equal(t, string(body), string(again), "The parsed message should marshal back unchanged")
+		}
+	}
+}
+
+func TestParseRequestBody_modify(t *testing.T) {
+	m, err := ParseRequestBody(GetRequestBody(newFullMail()))
+	assert.Nil(t, err)
+	m.SetIPPoolID("other_pool").AddCategories("queued")
+	assert.Equal(t, "other_pool", m.IPPoolID)
+	assert.Equal(t, []string{"category", "queued"}, m.Categories)
+	assert.Equal(t, "to@example.com", m.Personalizations[0].To[0].Address)
+	items := m.Personalizations[0].DynamicTemplateData["items"].([]interface{})
+	assert.Equal(t, "widget", items[0].(map[string]interface{})["name"])
+}
+
+func TestParseRequestBody_unknownFields(t *testing.T) {
+	bodies := []string{
+		`{"from":{"email":"from@example.com"},"unknown":true}`,
+		`{"personalizations":[{"to":[{"email":"to@example.com"}],"unknown":true}]}`,
+		`{"personalizations":[{"to":[{"email":"to@example.com","unknown":true}]}]}`,
+		`{"mail_settings":{"sandbox_mode":{"enable":true,"unknown":true}}}`,
+	}
+	for _, body := range bodies {
+		_, err := ParseRequestBody([]byte(body))
+		assert.Nil(t, err, "Unknown fields should be dropped: "+body)
+		_, err = ParseRequestBodyStrict([]byte(body))
+		assert.NotNil(t, err, "Unknown fields should be rejected in strict mode: "+body)
+	}
+}
+
+func TestParseRequestBody_invalid(t *testing.T) {
+	_, err := ParseRequestBody([]byte(`{"personalizations":{}}`))
+	assert.NotNil(t, err)
+	_, err = ParseRequestBody([]byte(`not json`))
+	assert.NotNil(t, err)
+	_, err = ParseRequestBodyStrict([]byte(`{"subject":"a"} {"subject":"b"}`))
+	assert.NotNil(t, err, "Trailing data should be rejected in strict mode")
+}
@@ -0,0 +1,280 @@
+package mail
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Boundaries of the rendered multipart bodies. They start with "=_", which
+// can not appear in quoted-printable or base64 encoded parts, so they are
+// fixed and the output of RenderMIME can be diffed.
+const (
+	mixedBoundary       = "=_sendgrid_mixed"
+	relatedBoundary     = "=_sendgrid_related"
+	alternativeBoundary = "=_sendgrid_alternative"
+)
+
+// base64LineLength is the maximum length of base64 lines in MIME bodies
+const base64LineLength = 76
+
+// formatAddress formats an email for a message header
+func formatAddress(e *Email) string {
+	return (&mail.Address{Name: e.Name, Address: e.Address}).String()
+}
+
+// RenderMIME renders the message as sent to one of its personalizations
+// into an RFC 5322 message. See WriteMIME.
+func (s *SGMailV3) RenderMIME(p *Personalization) ([]byte, error) {
+	var b bytes.Buffer
+	if err := s.WriteMIME(&b, p); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// WriteMIME writes the message as sent to one of its personalizations as an
+// RFC 5322 message: a multipart/mixed body holding the content as
+// multipart/alternative, wrapped in multipart/related with the inline
+// parts when there are any, followed by the attachments.
+//
+// BCC recipients are left out of the headers. Templates, substitutions and
+// sections are not applied; they are carried in the X-SMTPAPI header for
+// SendGrid to process.
+func (s *SGMailV3) WriteMIME(w io.Writer, p *Personalization) error {
+	if p == nil {
+		return errors.New("mail: a personalization is required to render a message")
+	}
+	if s.From == nil {
+		return errors.New("mail: a from address is required to render a message")
+	}
+
+	var b bytes.Buffer
+	if err := s.writeHeaders(&b, p); err != nil {
+		return err
+	}
+
+	var inline, attached []*Attachment
+	for _, a := range s.Attachments {
+		if a == nil {
+			continue
+		}
+		if a.Disposition == "inline" {
+			inline = append(inline, a)
+		} else {
+			attached = append(attached, a)
+		}
+	}
+
+	mixed := multipart.NewWriter(&b)
+	if err := mixed.SetBoundary(mixedBoundary); err != nil {
+		return err
+	}
+	writeHeader(&b, "Content-Type", mime.FormatMediaType("multipart/mixed", map[string]string{"boundary": mixedBoundary}))
+	b.WriteString("\r\n")
+
+	if len(inline) > 0 {
+		related, err := nestedWriter(mixed, "multipart/related", relatedBoundary)
+		if err != nil {
+			return err
+		}
+		if err := s.writeContent(related); err != nil {
+			return err
+		}
+		for _, a := range inline {
+			if err := writeAttachment(related, a); err != nil {
+				return err
+			}
+		}
+		if err := related.Close(); err != nil {
+			return err
+		}
+	} else if err := s.writeContent(mixed); err != nil {
+		return err
+	}
+
+	for _, a := range attached {
+		if err := writeAttachment(mixed, a); err != nil {
+			return err
+		}
+	}
+	if err := mixed.Close(); err != nil {
+		return err
+	}
+
+	_, err := b.WriteTo(w)
+	return err
+}
+
+// writeHeaders writes the top level headers, except Content-Type
+func (s *SGMailV3) writeHeaders(b *bytes.Buffer, p *Personalization) error {
+	date := time.Now()
+	if p.SendAt != 0 {
+		date = time.Unix(int64(p.SendAt), 0)
+	} else if s.SendAt != 0 {
+		date = time.Unix(int64(s.SendAt), 0)
+	}
+	subject := s.Subject
+	if p.Subject != "" {
+		subject = p.Subject
+	}
+
+	writeHeader(b, "From", formatAddress(s.From))
+	writeAddressHeader(b, "To", p.To)
+	writeAddressHeader(b, "Cc", p.CC)
+	if s.ReplyTo != nil {
+		writeHeader(b, "Reply-To", formatAddress(s.ReplyTo))
+	}
+	writeHeader(b, "Subject", mime.QEncoding.Encode("utf-8", subject))
+	writeHeader(b, "Date", date.UTC().Format(time.RFC1123Z))
+	writeHeader(b, "MIME-Version", "1.0")
+
+	headers := make(map[string]string, len(s.Headers)+len(p.Headers))
+	for k, v := range s.Headers {
+		headers[k] = v
+	}
+	for k, v := range p.Headers {
+		headers[k] = v
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		writeHeader(b, name, mime.QEncoding.Encode("utf-8", headers[name]))
+	}
+
+	smtpapi := NewSMTPAPIHeader(s, p)
+	if !smtpapi.IsEmpty() {
+		value, err := smtpapi.HeaderValue()
+		if err != nil {
+			return err
+		}
+		writeHeader(b, "X-SMTPAPI", value)
+	}
+	return nil
+}
+
+// writeContent writes the content as a multipart/alternative part
+func (s *SGMailV3) writeContent(parent *multipart.Writer) error {
+	if len(s.Content) == 0 {
+		return nil
+	}
+	alternative, err := nestedWriter(parent, "multipart/alternative", alternativeBoundary)
+	if err != nil {
+		return err
+	}
+	for _, c := range s.Content {
+		if c == nil {
+			continue
+		}
+		mediaType, params, err := mime.ParseMediaType(c.Type)
+		if err != nil {
+			return fmt.Errorf("mail: invalid content type %q: %v", c.Type, err)
+		}
+		if _, ok := params["charset"]; !ok && strings.HasPrefix(mediaType, "text/") {
+			params["charset"] = "utf-8"
+		}
+		part, err := alternative.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {mime.FormatMediaType(mediaType, params)},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return err
+		}
+		qp := quotedprintable.NewWriter(part)
+		if _, err := qp.Write([]byte(c.Value)); err != nil {
+			return err
+		}
+		if err := qp.Close(); err != nil {
+			return err
+		}
+	}
+	return alternative.Close()
+}
+
+// nestedWriter starts a multipart part of parent
+func nestedWriter(parent *multipart.Writer, mediaType, boundary string) (*multipart.Writer, error) {
+	part, err := parent.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {mime.FormatMediaType(mediaType, map[string]string{"boundary": boundary})},
+	})
+	if err != nil {
+		return nil, err
+	}
+	w := multipart.NewWriter(part)
+	return w, w.SetBoundary(boundary)
+}
+
+// writeAttachment writes an attachment as a base64 encoded part
+func writeAttachment(parent *multipart.Writer, a *Attachment) error {
+	contentType := a.Type
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("mail: invalid attachment type %q: %v", a.Type, err)
+	}
+	disposition := a.Disposition
+	if disposition == "" {
+		disposition = "attachment"
+	}
+	header := textproto.MIMEHeader{
+		"Content-Transfer-Encoding": {"base64"},
+	}
+	if a.Filename != "" {
+		params["name"] = a.Filename
+		header.Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": a.Filename}))
+	} else {
+		header.Set("Content-Disposition", disposition)
+	}
+	header.Set("Content-Type", mime.FormatMediaType(mediaType, params))
+	if a.ContentID != "" {
+		header.Set("Content-Id", "<"+a.ContentID+">")
+	}
+
+	part, err := parent.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	content := a.Content
+	for len(content) > base64LineLength {
+		if _, err := io.WriteString(part, content[:base64LineLength]+"\r\n"); err != nil {
+			return err
+		}
+		content = content[base64LineLength:]
+	}
+	_, err = io.WriteString(part, content+"\r\n")
+	return err
+}
+
+// writeHeader writes a header line
+func writeHeader(b *bytes.Buffer, name, value string) {
+	b.WriteString(name)
+	b.WriteString(": ")
+	b.WriteString(value)
+	b.WriteString("\r\n")
+}
+
+// writeAddressHeader writes a header listing addresses, if there are any
+func writeAddressHeader(b *bytes.Buffer, name string, emails []*Email) {
+	addresses := make([]string, 0, len(emails))
+	for _, e := range emails {
+		if e != nil {
+			addresses = append(addresses, formatAddress(e))
+		}
+	}
+	if len(addresses) > 0 {
+		writeHeader(b, name, strings.Join(addresses, ",\r\n "))
+	}
+}
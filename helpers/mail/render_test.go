@@ -0,0 +1,226 @@
+package mail
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	netmail "net/mail"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mimePart is a decoded leaf of a rendered message
+type mimePart struct {
+	path   string
+	header map[string][]string
+	body   string
+}
+
+// walkMIME decodes the leaves of a multipart body, recording the media
+// types leading to each of them
+func walkMIME(t *testing.T, path, contentType string, r io.Reader, header map[string][]string) []mimePart {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if !assert.Nil(t, err) {
+		return nil
+	}
+	path += "/" + mediaType
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		var body []byte
+		switch strings.ToLower(firstValue(header, "Content-Transfer-Encoding")) {
+		case "quoted-printable":
+			body, err = ioutil.ReadAll(quotedprintable.NewReader(r))
+		case "base64":
+			body, err = ioutil.ReadAll(base64.NewDecoder(base64.StdEncoding, r))
+		default:
+			body, err = ioutil.ReadAll(r)
+		}
+		assert.Nil(t, err)
+		return []mimePart{{path: path, header: header, body: string(body)}}
+	}
+
+	var parts []mimePart
+	mr := multipart.NewReader(r, params["boundary"])
+	for {
+		p, err := mr.NextRawPart()
+		if err == io.EOF {
+			return parts
+		}
+		if !assert.Nil(t, err) {
+			return parts
+		}
+		parts = append(parts, walkMIME(t, path, p.Header.Get("Content-Type"), p, p.Header)...)
+	}
+}
+
+func firstValue(header map[string][]string, key string) string {
+	if v := header[key]; len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+func TestRenderMIME(t *testing.T) {
+	m := newFullMail()
+	m.Attachments[0].SetDisposition("attachment")
+	m.From.Name = "Ünïcode Sender"
+	m.Personalizations[0].Subject = "Grüße"
+	raw, err := m.RenderMIME(m.Personalizations[0])
+	assert.Nil(t, err)
+
+	msg, err := netmail.ReadMessage(bytes.NewReader(raw))
+	if !assert.Nil(t, err) {
+		return
+	}
+	dec := new(mime.WordDecoder)
+	from, err := msg.Header.AddressList("From")
+	assert.Nil(t, err)
+	assert.Equal(t, "Ünïcode Sender", from[0].Name)
+	to, _ := msg.Header.AddressList("To")
+	assert.Equal(t, "to@example.com", to[0].Address)
+	cc, _ := msg.Header.AddressList("Cc")
+	assert.Equal(t, "cc@example.com", cc[0].Address)
+	assert.Equal(t, "", msg.Header.Get("Bcc"), "BCC recipients should not be listed")
+	subject, _ := dec.DecodeHeader(msg.Header.Get("Subject"))
+	assert.Equal(t, "Grüße", subject)
+	assert.Equal(t, "Fri, 29 Aug 2014 21:41:53 +0000", msg.Header.Get("Date"))
+	assert.Equal(t, "1.0", msg.Header.Get("MIME-Version"))
+	assert.Equal(t, "message", msg.Header.Get("X-Message"))
+	assert.Equal(t, "test", msg.Header.Get("X-Test"))
+
+	var smtpapi map[string]interface{}
+	assert.Nil(t, json.Unmarshal([]byte(msg.Header.Get("X-SMTPAPI")), &smtpapi), "X-SMTPAPI should unfold into JSON")
+	assert.Equal(t, []interface{}{"Example"}, smtpapi["sub"].(map[string]interface{})["-name-"])
+	assert.Equal(t, []interface{}{"category", "personal"}, smtpapi["category"])
+	assert.Equal(t, map[string]interface{}{"campaign": "welcome", "user_id": "343"}, smtpapi["unique_args"])
+	assert.Equal(t, float64(1), smtpapi["asm_group_id"])
+	assert.Equal(t, float64(1409348513), smtpapi["send_at"])
+	assert.Equal(t, "pool", smtpapi["ip_pool"])
+	for _, line := range strings.Split(string(raw), "\r\n") {
+		assert.True(t, len(line) <= 998, "Lines should fit RFC 5322")
+	}
+
+	parts := walkMIME(t, "", msg.Header.Get("Content-Type"), msg.Body, nil)
+	if assert.Equal(t, 3, len(parts)) {
+		assert.Equal(t, "/multipart/mixed/multipart/alternative/text/plain", parts[0].path)
+		assert.Equal(t, "text", parts[0].body)
+		assert.Equal(t, "/multipart/mixed/multipart/alternative/text/html", parts[1].path)
+		assert.Equal(t, "<p>html</p>", parts[1].body)
+		assert.Equal(t, "/multipart/mixed/text/plain", parts[2].path)
+		assert.Equal(t, "hello", parts[2].body)
+		assert.Equal(t, `attachment; filename=hello.txt`, firstValue(parts[2].header, "Content-Disposition"))
+	}
+}
+
+func TestRenderMIME_inline(t *testing.T) {
+	m := validMail()
+	logo, err := NewInlineAttachment("logo", bytes.NewReader(pngHeader))
+	assert.Nil(t, err)
+	report, err := NewAttachmentFromBytes("report.csv", bytes.Repeat([]byte("a,b,c\n"), 100))
+	assert.Nil(t, err)
+	m.AddAttachment(logo, report)
+	raw, err := m.RenderMIME(m.Personalizations[0])
+	assert.Nil(t, err)
+
+	msg, err := netmail.ReadMessage(bytes.NewReader(raw))
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, "", msg.Header.Get("X-SMTPAPI"), "An empty X-SMTPAPI header should be left out")
+	parts := walkMIME(t, "", msg.Header.Get("Content-Type"), msg.Body, nil)
+	if assert.Equal(t, 4, len(parts)) {
+		assert.Equal(t, "/multipart/mixed/multipart/related/multipart/alternative/text/plain", parts[0].path)
+		assert.Equal(t, "/multipart/mixed/multipart/related/multipart/alternative/text/html", parts[1].path)
+		assert.Equal(t, "/multipart/mixed/multipart/related/image/png", parts[2].path)
+		assert.Equal(t, "<logo>", firstValue(parts[2].header, "Content-Id"))
+		assert.Equal(t, string(pngHeader), parts[2].body)
+		assert.Equal(t, "/multipart/mixed/text/csv", parts[3].path)
+		assert.Equal(t, strings.Repeat("a,b,c\n", 100), parts[3].body)
+	}
+}
+
+func TestRenderMIME_deterministic(t *testing.T) {
+	m := newFullMail()
+	first, err := m.RenderMIME(m.Personalizations[0])
+	assert.Nil(t, err)
+	second, err := m.RenderMIME(m.Personalizations[0])
+	assert.Nil(t, err)
+	assert.Equal(t, string(first), string(second))
+}
+
+func TestRenderMIME_errors(t *testing.T) {
+	m := validMail()
+	_, err := m.RenderMIME(nil)
+	assert.NotNil(t, err)
+	m.From = nil
+	_, err = m.RenderMIME(m.Personalizations[0])
+	assert.NotNil(t, err)
+	m = validMail()
+	m.Content[0].Type = "not a type;;"
+	_, err = m.RenderMIME(m.Personalizations[0])
+	assert.NotNil(t, err)
+}
+
+func TestNewSMTPAPIHeader_substitutions(t *testing.T) {
+	m := validMail()
+	p := m.Personalizations[0]
+	p.AddTos(NewEmail("Second", "second@example.com"))
+	p.SetSubstitution("-code-", "42")
+	h := NewSMTPAPIHeader(m, p)
+	assert.Equal(t, []string{"\"Example User\" <to@example.com>", "\"Second\" <second@example.com>"}, h.To)
+	assert.Equal(t, []string{"42", "42"}, h.Sub["-code-"])
+
+	value, err := h.HeaderValue()
+	assert.Nil(t, err)
+	var decoded SMTPAPIHeader
+	assert.Nil(t, json.Unmarshal([]byte(strings.Replace(value, "\r\n", "", -1)), &decoded))
+	assert.Equal(t, *h, decoded)
+}
+
+func TestSMTPAPIHeader_HeaderValue_folding(t *testing.T) {
+	long := strings.Repeat("lorem ipsum ", 167)[:2000]
+	h := &SMTPAPIHeader{
+		UniqueArgs: map[string]string{"long": long, "name": "Grüße 😀"},
+	}
+	value, err := h.HeaderValue()
+	assert.Nil(t, err)
+	for _, line := range strings.Split(value, "\r\n") {
+		assert.True(t, len(line) <= 998, "Lines should fit RFC 5322")
+		assert.True(t, len(line) <= 80, "Lines should be folded at spaces inside strings")
+		for _, r := range line {
+			assert.True(t, r < 0x80, "Non-ASCII should be escaped")
+		}
+	}
+	assert.Contains(t, value, `"Gr\u00fc\u00dfe \ud83d\ude00"`)
+
+	// unfolding removes the CRLFs and keeps the spaces that follow them
+	var decoded SMTPAPIHeader
+	assert.Nil(t, json.Unmarshal([]byte(strings.Replace(value, "\r\n", "", -1)), &decoded))
+	assert.Equal(t, *h, decoded)
+
+	h.UniqueArgs["long"] = strings.Repeat("x", 2000)
+	_, err = h.HeaderValue()
+	assert.NotNil(t, err, "A value without spaces longer than a header line should return an error")
+}
+
+func TestNewSMTPAPIHeader_filters(t *testing.T) {
+	m := newFullMail()
+	h := NewSMTPAPIHeader(m, m.Personalizations[0])
+	assert.Equal(t, map[string]interface{}{"enable": 1, "template_id": "d-123"}, h.Filters["templates"].Settings)
+	assert.Equal(t, map[string]interface{}{"enable": 1, "email": "bcc@example.com"}, h.Filters["bcc"].Settings)
+	assert.Equal(t, map[string]interface{}{"enable": 0}, h.Filters["bypass_list_management"].Settings)
+	assert.Equal(t, map[string]interface{}{"enable": 1, "text/plain": "footer", "text/html": "<p>footer</p>"}, h.Filters["footer"].Settings)
+	assert.Equal(t, map[string]interface{}{"enable": 1, "maxscore": 5, "url": "https://example.com"}, h.Filters["spamcheck"].Settings)
+	assert.Equal(t, map[string]interface{}{"enable": 1, "enable_text": 0}, h.Filters["clicktrack"].Settings)
+	assert.Equal(t, map[string]interface{}{"enable": 1, "replace": "%open%"}, h.Filters["opentrack"].Settings)
+	assert.Equal(t, "%unsub%", h.Filters["subscriptiontrack"].Settings["replace"])
+	assert.Equal(t, "source", h.Filters["ganalytics"].Settings["utm_source"])
+
+	assert.Nil(t, NewSMTPAPIHeader(validMail(), validMail().Personalizations[0]).Filters)
+}
@@ -0,0 +1,247 @@
+package mail
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// SMTPAPIHeader is the X-SMTPAPI header that carries SendGrid specific
+// settings on messages sent through the SMTP relay
+type SMTPAPIHeader struct {
+	To                 []string            `json:"to,omitempty"`
+	Sub                map[string][]string `json:"sub,omitempty"`
+	Section            map[string]string   `json:"section,omitempty"`
+	Category           []string            `json:"category,omitempty"`
+	UniqueArgs         map[string]string   `json:"unique_args,omitempty"`
+	ASMGroupID         int                 `json:"asm_group_id,omitempty"`
+	ASMGroupsToDisplay []int               `json:"asm_groups_to_display,omitempty"`
+	SendAt             int                 `json:"send_at,omitempty"`
+	IPPool             string              `json:"ip_pool,omitempty"`
+	Filters            map[string]*Filter  `json:"filters,omitempty"`
+}
+
+// Filter holds the settings of an X-SMTPAPI filter, the SMTP counterpart of
+// mail and tracking settings
+type Filter struct {
+	Settings map[string]interface{} `json:"settings"`
+}
+
+// NewSMTPAPIHeader builds the X-SMTPAPI header sending the message to one
+// personalization. Values set on the personalization take precedence over
+// those of the message.
+//
+// Substitutions are listed once per To recipient, which makes SendGrid
+// deliver a separate copy to each of them.
+func NewSMTPAPIHeader(s *SGMailV3, p *Personalization) *SMTPAPIHeader {
+	h := &SMTPAPIHeader{
+		Section: s.Sections,
+		IPPool:  s.IPPoolID,
+		SendAt:  s.SendAt,
+	}
+	if p.SendAt != 0 {
+		h.SendAt = p.SendAt
+	}
+
+	if len(p.Substitutions) > 0 && len(p.To) > 0 {
+		h.Sub = make(map[string][]string, len(p.Substitutions))
+		for _, to := range p.To {
+			h.To = append(h.To, formatAddress(to))
+		}
+		for k, v := range p.Substitutions {
+			values := make([]string, len(p.To))
+			for i := range values {
+				values[i] = v
+			}
+			h.Sub[k] = values
+		}
+	}
+
+	h.Category = append(append([]string(nil), s.Categories...), p.Categories...)
+	if len(h.Category) == 0 {
+		h.Category = nil
+	}
+
+	if len(s.CustomArgs)+len(p.CustomArgs) > 0 {
+		h.UniqueArgs = make(map[string]string, len(s.CustomArgs)+len(p.CustomArgs))
+		for k, v := range s.CustomArgs {
+			h.UniqueArgs[k] = v
+		}
+		for k, v := range p.CustomArgs {
+			h.UniqueArgs[k] = v
+		}
+	}
+
+	if s.Asm != nil {
+		h.ASMGroupID = s.Asm.GroupID
+		h.ASMGroupsToDisplay = s.Asm.GroupsToDisplay
+	}
+
+	h.Filters = newFilters(s)
+	if len(h.Filters) == 0 {
+		h.Filters = nil
+	}
+	return h
+}
+
+// enableFlag converts an enable setting to the 0 or 1 filters expect
+func enableFlag(enable *bool) int {
+	if enable != nil && *enable {
+		return 1
+	}
+	return 0
+}
+
+// newFilters converts the template, mail and tracking settings of a message
+// to X-SMTPAPI filters
+func newFilters(s *SGMailV3) map[string]*Filter {
+	filters := make(map[string]*Filter)
+	add := func(name string, enable *bool, settings map[string]interface{}) {
+		if settings == nil {
+			settings = make(map[string]interface{})
+		}
+		settings["enable"] = enableFlag(enable)
+		for k, v := range settings {
+			if v == "" {
+				delete(settings, k)
+			}
+		}
+		filters[name] = &Filter{Settings: settings}
+	}
+
+	if s.TemplateID != "" {
+		enable := true
+		add("templates", &enable, map[string]interface{}{"template_id": s.TemplateID})
+	}
+
+	if ms := s.MailSettings; ms != nil {
+		if ms.BCC != nil {
+			add("bcc", ms.BCC.Enable, map[string]interface{}{"email": ms.BCC.Email})
+		}
+		if ms.BypassListManagement != nil {
+			add("bypass_list_management", ms.BypassListManagement.Enable, nil)
+		}
+		if ms.Footer != nil {
+			add("footer", ms.Footer.Enable, map[string]interface{}{"text/plain": ms.Footer.Text, "text/html": ms.Footer.Html})
+		}
+		if sc := ms.SpamCheckSetting; sc != nil {
+			settings := map[string]interface{}{"url": sc.PostToURL}
+			if sc.SpamThreshold != 0 {
+				settings["maxscore"] = sc.SpamThreshold
+			}
+			add("spamcheck", sc.Enable, settings)
+		}
+	}
+
+	if ts := s.TrackingSettings; ts != nil {
+		if ct := ts.ClickTracking; ct != nil {
+			add("clicktrack", ct.Enable, map[string]interface{}{"enable_text": enableFlag(ct.EnableText)})
+		}
+		if ot := ts.OpenTracking; ot != nil {
+			add("opentrack", ot.Enable, map[string]interface{}{"replace": ot.SubstitutionTag})
+		}
+		if st := ts.SubscriptionTracking; st != nil {
+			add("subscriptiontrack", st.Enable, map[string]interface{}{
+				"text/plain": st.Text,
+				"text/html":  st.Html,
+				"replace":    st.SubstitutionTag,
+			})
+		}
+		if ga := ts.GoogleAnalytics; ga != nil {
+			add("ganalytics", ga.Enable, map[string]interface{}{
+				"utm_source":   ga.CampaignSource,
+				"utm_medium":   ga.CampaignMedium,
+				"utm_term":     ga.CampaignTerm,
+				"utm_content":  ga.CampaignContent,
+				"utm_campaign": ga.CampaignName,
+			})
+		}
+	}
+	return filters
+}
+
+// IsEmpty reports whether the header carries no settings
+func (h *SMTPAPIHeader) IsEmpty() bool {
+	b, err := json.Marshal(h)
+	return err == nil && string(b) == "{}"
+}
+
+// maxHeaderLineLength is the RFC 5322 limit on a header line, and
+// foldLineLength the length lines are folded at when possible
+const (
+	maxHeaderLineLength = 998
+	foldLineLength      = 78
+)
+
+// HeaderValue returns the header JSON folded into lines short enough for
+// an RFC 5322 header. Non-ASCII characters are escaped as \uXXXX. Lines are
+// folded between JSON tokens and, inside long strings, at an existing space,
+// so the unfolded value is the same JSON. A string holding a run of more
+// than 998 characters without a space cannot be folded and is an error.
+func (h *SMTPAPIHeader) HeaderValue() (string, error) {
+	b, err := json.Marshal(h)
+	if err != nil {
+		return "", err
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, escapeNonASCII(b), "", ""); err != nil {
+		return "", err
+	}
+	var lines []string
+	for _, line := range strings.Split(indented.String(), "\n") {
+		folded, err := foldHeaderLine(line)
+		if err != nil {
+			return "", err
+		}
+		lines = append(lines, folded...)
+	}
+	return strings.Join(lines, "\r\n "), nil
+}
+
+// escapeNonASCII replaces the non-ASCII characters of JSON, which can only
+// appear inside strings, with \uXXXX escapes
+func escapeNonASCII(b []byte) []byte {
+	var escaped bytes.Buffer
+	for _, r := range string(b) {
+		if r < utf8.RuneSelf {
+			escaped.WriteRune(r)
+			continue
+		}
+		if r1, r2 := utf16.EncodeRune(r); r1 != unicode.ReplacementChar {
+			fmt.Fprintf(&escaped, "\\u%04x\\u%04x", r1, r2)
+			continue
+		}
+		fmt.Fprintf(&escaped, "\\u%04x", r)
+	}
+	return escaped.Bytes()
+}
+
+// foldHeaderLine splits a line at spaces so the parts fit foldLineLength
+// where possible, and maxHeaderLineLength always. The space a line is split
+// at is dropped, as the "\r\n " the parts are joined with puts it back.
+func foldHeaderLine(line string) ([]string, error) {
+	var lines []string
+	for len(line) > foldLineLength {
+		i := strings.LastIndexByte(line[:foldLineLength+1], ' ')
+		if i <= 0 {
+			next := strings.IndexByte(line[foldLineLength:], ' ')
+			if next < 0 {
+				break
+			}
+			i = foldLineLength + next
+		}
+		lines = append(lines, line[:i])
+		line = line[i+1:]
+	}
+	lines = append(lines, line)
+	for _, l := range lines {
+		if len(l)+1 > maxHeaderLineLength {
+			return nil, fmt.Errorf("mail: X-SMTPAPI value has a line of %d characters that cannot be folded", len(l))
+		}
+	}
+	return lines, nil
+}
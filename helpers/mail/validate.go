@@ -0,0 +1,268 @@
+package mail
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Limits of the v3 mail/send endpoint
+const (
+	MaxPersonalizations = 1000
+	MaxRecipients       = 1000
+	MaxCustomArgsSize   = 10000
+	MaxCategories       = 10
+	MaxCategoryLength   = 255
+)
+
+// reservedHeaders can not be set through the headers of a message or of a
+// personalization
+var reservedHeaders = map[string]bool{
+	"x-sg-id":                   true,
+	"x-sg-eid":                  true,
+	"received":                  true,
+	"dkim-signature":            true,
+	"content-type":              true,
+	"content-transfer-encoding": true,
+	"to":                        true,
+	"from":                      true,
+	"subject":                   true,
+	"reply-to":                  true,
+	"cc":                        true,
+	"bcc":                       true,
+}
+
+// FieldError describes a field of a message violating a mail/send
+// constraint
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// Error implements the error interface
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ValidationErrors lists every constraint violated by a message
+type ValidationErrors []*FieldError
+
+// Error implements the error interface
+func (v ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(v))
+	for _, e := range v {
+		msgs = append(msgs, e.Error())
+	}
+	return "mail: invalid message: " + strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the individual field errors
+func (v ValidationErrors) Unwrap() []error {
+	errs := make([]error, 0, len(v))
+	for _, e := range v {
+		errs = append(errs, e)
+	}
+	return errs
+}
+
+// Is reports whether one of the field errors matches target. errors.Is only
+// follows Unwrap() []error from Go 1.20.
+func (v ValidationErrors) Is(target error) bool {
+	for _, e := range v {
+		if errors.Is(e, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first field error that matches target. errors.As only
+// follows Unwrap() []error from Go 1.20.
+func (v ValidationErrors) As(target interface{}) bool {
+	for _, e := range v {
+		if errors.As(e, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// validator accumulates field errors
+type validator struct {
+	errs ValidationErrors
+}
+
+func (v *validator) add(field, format string, args ...interface{}) {
+	v.errs = append(v.errs, &FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Validate checks the message against the documented constraints of the v3
+// mail/send endpoint. It returns ValidationErrors listing every violation,
+// or nil if none was found.
+func (s *SGMailV3) Validate() error {
+	v := &validator{}
+
+	if s.From == nil || s.From.Address == "" {
+		v.add("from", "is required")
+	}
+	if s.ReplyTo != nil && s.ReplyTo.Address == "" {
+		v.add("reply_to.email", "is required")
+	}
+
+	s.validatePersonalizations(v)
+	s.validateContent(v)
+	s.validateAttachments(v)
+	validateHeaders(v, "headers", s.Headers)
+	validateCategories(v, "categories", s.Categories)
+
+	if err := v.errs; len(err) > 0 {
+		return err
+	}
+	return nil
+}
+
+func (s *SGMailV3) validatePersonalizations(v *validator) {
+	if len(s.Personalizations) == 0 {
+		v.add("personalizations", "at least one personalization is required")
+		return
+	}
+	if len(s.Personalizations) > MaxPersonalizations {
+		v.add("personalizations", "has %d personalizations, the maximum is %d", len(s.Personalizations), MaxPersonalizations)
+	}
+
+	recipients := 0
+	for i, p := range s.Personalizations {
+		field := fmt.Sprintf("personalizations[%d]", i)
+		if p == nil {
+			v.add(field, "is nil")
+			continue
+		}
+		if len(p.To) == 0 {
+			v.add(field+".to", "at least one recipient is required")
+		}
+		if s.Subject == "" && p.Subject == "" && s.TemplateID == "" {
+			v.add(field+".subject", "is required when the message has no subject or template_id")
+		}
+
+		seen := make(map[string]string)
+		kinds := []struct {
+			name   string
+			emails []*Email
+		}{{"to", p.To}, {"cc", p.CC}, {"bcc", p.BCC}}
+		for _, kind := range kinds {
+			recipients += len(kind.emails)
+			for j, e := range kind.emails {
+				emailField := fmt.Sprintf("%s.%s[%d]", field, kind.name, j)
+				if e == nil || e.Address == "" {
+					v.add(emailField+".email", "is required")
+					continue
+				}
+				address := strings.ToLower(e.Address)
+				if first, ok := seen[address]; ok {
+					v.add(emailField+".email", "%s is duplicated in %s", e.Address, first)
+				} else {
+					seen[address] = emailField
+				}
+			}
+		}
+
+		validateHeaders(v, field+".headers", p.Headers)
+		validateCategories(v, field+".categories", p.Categories)
+		s.validateCustomArgs(v, field+".custom_args", p.CustomArgs)
+	}
+
+	if recipients > MaxRecipients {
+		v.add("personalizations", "has %d recipients, the maximum is %d", recipients, MaxRecipients)
+	}
+}
+
+func (s *SGMailV3) validateContent(v *validator) {
+	if len(s.Content) == 0 && s.TemplateID == "" {
+		v.add("content", "is required when the message has no template_id")
+	}
+	for i, c := range s.Content {
+		field := fmt.Sprintf("content[%d]", i)
+		if c == nil {
+			v.add(field, "is nil")
+			continue
+		}
+		if c.Type == "" {
+			v.add(field+".type", "is required")
+		}
+		if c.Value == "" {
+			v.add(field+".value", "is required")
+		}
+		if strings.EqualFold(c.Type, "text/plain") && i != 0 {
+			v.add(field+".type", "text/plain must be the first content, before text/html")
+		}
+	}
+}
+
+func (s *SGMailV3) validateAttachments(v *validator) {
+	size := 0
+	for i, a := range s.Attachments {
+		field := fmt.Sprintf("attachments[%d]", i)
+		if a == nil {
+			v.add(field, "is nil")
+			continue
+		}
+		size += len(a.Content)
+		if a.Content == "" {
+			v.add(field+".content", "is required")
+		}
+		if a.Filename == "" {
+			v.add(field+".filename", "is required")
+		}
+		if a.Disposition == "inline" && a.ContentID == "" {
+			v.add(field+".content_id", "is required for inline attachments")
+		}
+	}
+	if size > MaxMessageSize {
+		v.add("attachments", "total %d bytes, the maximum is %d", size, MaxMessageSize)
+	}
+}
+
+// validateCustomArgs checks the size of the custom args of a
+// personalization combined with those of the message
+func (s *SGMailV3) validateCustomArgs(v *validator, field string, args map[string]string) {
+	merged := make(map[string]string, len(s.CustomArgs)+len(args))
+	for k, val := range s.CustomArgs {
+		merged[k] = val
+	}
+	for k, val := range args {
+		merged[k] = val
+	}
+	if len(merged) == 0 {
+		return
+	}
+	b, err := json.Marshal(merged)
+	if err == nil && len(b) > MaxCustomArgsSize {
+		v.add(field, "custom_args total %d bytes, the maximum is %d", len(b), MaxCustomArgsSize)
+	}
+}
+
+func validateHeaders(v *validator, field string, headers map[string]string) {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if reservedHeaders[strings.ToLower(name)] {
+			v.add(field+"."+name, "is a reserved header")
+		}
+	}
+}
+
+func validateCategories(v *validator, field string, categories []string) {
+	if len(categories) > MaxCategories {
+		v.add(field, "has %d categories, the maximum is %d", len(categories), MaxCategories)
+	}
+	for i, c := range categories {
+		if len(c) > MaxCategoryLength {
+			v.add(fmt.Sprintf("%s[%d]", field, i), "is longer than %d characters", MaxCategoryLength)
+		}
+	}
+}
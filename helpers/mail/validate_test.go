@@ -0,0 +1,157 @@
+package mail
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func validMail() *SGMailV3 {
+	from := NewEmail("Example User", "from@example.com")
+	to := NewEmail("Example User", "to@example.com")
+	return NewSingleEmail(from, "subject", to, "text", "<p>html</p>")
+}
+
+func fieldsOf(err error) []string {
+	var verrs ValidationErrors
+	if !errors.As(err, &verrs) {
+		return nil
+	}
+	fields := make([]string, 0, len(verrs))
+	for _, e := range verrs {
+		fields = append(fields, e.Field)
+	}
+	return fields
+}
+
+func TestValidate_valid(t *testing.T) {
+	assert.Nil(t, validMail().Validate())
+
+	m := NewV3Mail()
+	m.SetFrom(NewEmail("", "from@example.com"))
+	m.SetTemplateID("d-123")
+	p := NewPersonalization()
+	p.AddTos(NewEmail("", "to@example.com"))
+	m.AddPersonalizations(p)
+	assert.Nil(t, m.Validate(), "Subject and content are optional with a template")
+}
+
+func TestValidate_empty(t *testing.T) {
+	err := NewV3Mail().Validate()
+	assert.Equal(t, []string{"from", "personalizations", "content"}, fieldsOf(err))
+	assert.True(t, strings.HasPrefix(err.Error(), "mail: invalid message: from: is required"))
+}
+
+func TestValidate_personalizations(t *testing.T) {
+	m := validMail()
+	p := NewPersonalization()
+	p.AddCCs(NewEmail("", "cc@example.com"))
+	m.AddPersonalizations(p)
+	m.Subject = ""
+	assert.Equal(t, []string{
+		"personalizations[0].subject",
+		"personalizations[1].to",
+		"personalizations[1].subject",
+	}, fieldsOf(m.Validate()))
+}
+
+func TestValidate_duplicates(t *testing.T) {
+	m := validMail()
+	p := m.Personalizations[0]
+	p.AddCCs(NewEmail("", "TO@example.com"))
+	p.AddBCCs(NewEmail("", "bcc@example.com"), NewEmail("", "bcc@example.com"))
+	err := m.Validate()
+	assert.Equal(t, []string{"personalizations[0].cc[0].email", "personalizations[0].bcc[1].email"}, fieldsOf(err))
+	assert.True(t, strings.Contains(err.Error(), "TO@example.com is duplicated in personalizations[0].to[0]"))
+}
+
+func TestValidate_limits(t *testing.T) {
+	m := validMail()
+	for i := 0; i < MaxPersonalizations; i++ {
+		p := NewPersonalization()
+		p.AddTos(NewEmail("", fmt.Sprintf("to%d@example.com", i)))
+		m.AddPersonalizations(p)
+	}
+	assert.Equal(t, []string{"personalizations", "personalizations"}, fieldsOf(m.Validate()),
+		"Both the personalization and the recipient limits should be reported")
+
+	m = validMail()
+	for i := 0; i < MaxRecipients; i++ {
+		m.Personalizations[0].AddBCCs(NewEmail("", fmt.Sprintf("bcc%d@example.com", i)))
+	}
+	err := m.Validate()
+	assert.Equal(t, []string{"personalizations"}, fieldsOf(err))
+	assert.True(t, strings.Contains(err.Error(), "has 1001 recipients"))
+}
+
+func TestValidate_contentOrder(t *testing.T) {
+	m := validMail()
+	m.Content = []*Content{NewContent("text/html", "<p>html</p>"), NewContent("text/plain", "text")}
+	assert.Equal(t, []string{"content[1].type"}, fieldsOf(m.Validate()))
+
+	m.Content = []*Content{NewContent("text/html", "")}
+	assert.Equal(t, []string{"content[0].value"}, fieldsOf(m.Validate()))
+}
+
+func TestValidate_headersAndCategories(t *testing.T) {
+	m := validMail()
+	m.SetHeader("X-Custom", "ok")
+	m.SetHeader("Reply-To", "nope@example.com")
+	m.Personalizations[0].SetHeader("DKIM-Signature", "nope")
+	m.AddCategories(strings.Repeat("c", MaxCategoryLength+1))
+	for i := 0; i < MaxCategories; i++ {
+		m.Personalizations[0].Categories = append(m.Personalizations[0].Categories, "category")
+	}
+	m.Personalizations[0].Categories = append(m.Personalizations[0].Categories, "one too many")
+	assert.Equal(t, []string{
+		"personalizations[0].headers.DKIM-Signature",
+		"personalizations[0].categories",
+		"headers.Reply-To",
+		"categories[0]",
+	}, fieldsOf(m.Validate()))
+}
+
+func TestValidate_customArgsSize(t *testing.T) {
+	m := validMail()
+	m.SetCustomArg("big", strings.Repeat("a", MaxCustomArgsSize/2))
+	assert.Nil(t, m.Validate())
+	m.Personalizations[0].SetCustomArg("bigger", strings.Repeat("b", MaxCustomArgsSize/2))
+	assert.Equal(t, []string{"personalizations[0].custom_args"}, fieldsOf(m.Validate()))
+}
+
+func TestValidate_errorsIs(t *testing.T) {
+	m := validMail()
+	m.From = nil
+	var fieldErr *FieldError
+	assert.True(t, errors.As(m.Validate(), &fieldErr))
+	assert.Equal(t, "from", fieldErr.Field)
+
+	// the methods of ValidationErrors, not errors.As, find the field error
+	// before Go 1.20
+	err := m.Validate().(ValidationErrors)
+	fieldErr = nil
+	assert.True(t, err.As(&fieldErr))
+	assert.Equal(t, "from", fieldErr.Field)
+	assert.True(t, err.Is(err[0]))
+	assert.False(t, err.Is(errors.New("mail: other")))
+}
+
+func TestValidate_attachments(t *testing.T) {
+	m := validMail()
+	m.AddAttachment(NewAttachment(), NewAttachment().SetContent("aGVsbG8=").SetFilename("logo").SetDisposition("inline"))
+	assert.Equal(t, []string{
+		"attachments[0].content",
+		"attachments[0].filename",
+		"attachments[1].content_id",
+	}, fieldsOf(m.Validate()))
+
+	m = validMail()
+	half := strings.Repeat("A", MaxMessageSize/2)
+	m.AddAttachment(NewAttachment().SetContent(half).SetFilename("a"), NewAttachment().SetContent(half).SetFilename("b"))
+	assert.Nil(t, m.Validate())
+	m.AddAttachment(NewAttachment().SetContent("AAAA").SetFilename("c"))
+	assert.Equal(t, []string{"attachments"}, fieldsOf(m.Validate()))
+}
@@ -0,0 +1,186 @@
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timestampLayouts are the layouts accepted for formatDate timestamps
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// dateTokens are the format tokens understood by formatDate, longest first
+var dateTokens = []string{
+	"YYYY", "YY",
+	"MMMM", "MMM", "MM", "M",
+	"dddd", "ddd",
+	"DD", "D",
+	"HH", "H", "hh", "h",
+	"mm", "m",
+	"ss", "s",
+	"A", "a",
+	"ZZ", "Z",
+}
+
+// formatDate implements {{formatDate timestamp format [offset]}}. The
+// timestamp is an RFC 3339 string, a date, a time.Time or a number of
+// seconds since the epoch. The format uses the tokens of the dynamic
+// template editor, e.g. "MMMM DD, YYYY h:mm A"; offset is a UTC offset
+// such as -0800.
+func formatDate(timestamp interface{}, format string, offset interface{}) (string, error) {
+	t, err := parseTimestamp(timestamp)
+	if err != nil {
+		return "", err
+	}
+	if t.IsZero() {
+		return "", nil
+	}
+	t = t.UTC()
+	if o := stringify(offset); o != "" {
+		loc, err := parseOffset(o)
+		if err != nil {
+			return "", err
+		}
+		t = t.In(loc)
+	}
+
+	var b strings.Builder
+	for format != "" {
+		token := ""
+		for _, candidate := range dateTokens {
+			if strings.HasPrefix(format, candidate) {
+				token = candidate
+				break
+			}
+		}
+		if token == "" {
+			b.WriteByte(format[0])
+			format = format[1:]
+			continue
+		}
+		b.WriteString(formatToken(t, token))
+		format = format[len(token):]
+	}
+	return b.String(), nil
+}
+
+func parseTimestamp(v interface{}) (time.Time, error) {
+	switch v := v.(type) {
+	case nil:
+		return time.Time{}, nil
+	case time.Time:
+		return v, nil
+	case *time.Time:
+		if v == nil {
+			return time.Time{}, nil
+		}
+		return *v, nil
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return time.Time{}, fmt.Errorf("template: invalid timestamp %q", v)
+		}
+		return time.Unix(int64(f), 0), nil
+	case float64:
+		return time.Unix(int64(v), 0), nil
+	case int:
+		return time.Unix(int64(v), 0), nil
+	case int64:
+		return time.Unix(v, 0), nil
+	case string:
+		if v == "" {
+			return time.Time{}, nil
+		}
+		for _, layout := range timestampLayouts {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, nil
+			}
+		}
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return time.Unix(n, 0), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("template: invalid timestamp %v", v)
+}
+
+// parseOffset parses a UTC offset such as -0800, +05:30 or -8
+func parseOffset(s string) (*time.Location, error) {
+	sign := 1
+	digits := s
+	switch {
+	case strings.HasPrefix(s, "-"):
+		sign, digits = -1, s[1:]
+	case strings.HasPrefix(s, "+"):
+		digits = s[1:]
+	}
+	digits = strings.Replace(digits, ":", "", 1)
+	n, err := strconv.Atoi(digits)
+	if err != nil || digits == "" {
+		return nil, fmt.Errorf("template: invalid timezone offset %q", s)
+	}
+	hours, minutes := n, 0
+	if len(digits) > 2 {
+		hours, minutes = n/100, n%100
+	}
+	if hours > 14 || minutes > 59 {
+		return nil, fmt.Errorf("template: invalid timezone offset %q", s)
+	}
+	return time.FixedZone(s, sign*(hours*3600+minutes*60)), nil
+}
+
+func formatToken(t time.Time, token string) string {
+	switch token {
+	case "YYYY":
+		return strconv.Itoa(t.Year())
+	case "YY":
+		return t.Format("06")
+	case "MMMM":
+		return t.Format("January")
+	case "MMM":
+		return t.Format("Jan")
+	case "MM":
+		return t.Format("01")
+	case "M":
+		return strconv.Itoa(int(t.Month()))
+	case "dddd":
+		return t.Format("Monday")
+	case "ddd":
+		return t.Format("Mon")
+	case "DD":
+		return t.Format("02")
+	case "D":
+		return strconv.Itoa(t.Day())
+	case "HH":
+		return t.Format("15")
+	case "H":
+		return strconv.Itoa(t.Hour())
+	case "hh":
+		return t.Format("03")
+	case "h":
+		return t.Format("3")
+	case "mm":
+		return t.Format("04")
+	case "m":
+		return strconv.Itoa(t.Minute())
+	case "ss":
+		return t.Format("05")
+	case "s":
+		return strconv.Itoa(t.Second())
+	case "A":
+		return t.Format("PM")
+	case "a":
+		return t.Format("pm")
+	case "ZZ":
+		return t.Format("-0700")
+	case "Z":
+		return t.Format("-07:00")
+	}
+	return token
+}
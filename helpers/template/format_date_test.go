@@ -0,0 +1,36 @@
+package template
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatDate(t *testing.T) {
+	tests := []struct {
+		timestamp interface{}
+		format    string
+		offset    interface{}
+		expected  string
+	}{
+		{"2020-01-01T23:00:00.000Z", "MMMM DD, YYYY h:mm:ss A", nil, "January 01, 2020 11:00:00 PM"},
+		{"2020-01-01T23:00:00.000Z", "dddd D MMM YY HH:mm", "-0800", "Wednesday 1 Jan 20 15:00"},
+		{"2020-01-01T23:00:00Z", "YYYY-MM-DD Z", "+05:30", "2020-01-02 +05:30"},
+		{"2020-03-04", "M/D/YYYY", nil, "3/4/2020"},
+		{json.Number("1577919600"), "YYYY-MM-DD HH:mm ZZ", nil, "2020-01-01 23:00 +0000"},
+		{time.Date(2020, 1, 1, 9, 5, 7, 0, time.UTC), "H:m:s a", nil, "9:5:7 am"},
+		{nil, "YYYY", nil, ""},
+	}
+	for _, test := range tests {
+		out, err := formatDate(test.timestamp, test.format, test.offset)
+		assert.Nil(t, err)
+		assert.Equal(t, test.expected, out)
+	}
+
+	_, err := formatDate("yesterday", "YYYY", nil)
+	assert.NotNil(t, err)
+	_, err = formatDate("2020-01-01", "YYYY", "PST")
+	assert.NotNil(t, err)
+}
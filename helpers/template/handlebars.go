@@ -0,0 +1,407 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Template is a parsed handlebars template. Only the subset supported by
+// SendGrid dynamic templates is understood: {{value}} and {{{raw value}}}
+// expressions, the if, unless, each and equals block helpers with
+// {{else}} and {{else if ...}} branches, and the formatDate and insert
+// helpers.
+type Template struct {
+	nodes []node
+}
+
+// Parse parses a handlebars template
+func Parse(text string) (*Template, error) {
+	tokens, err := lex(text)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	nodes, stop, err := p.parseNodes()
+	if err != nil {
+		return nil, err
+	}
+	if stop != nil {
+		return nil, fmt.Errorf("template: unexpected {{%s}} at offset %d", stop.content, stop.pos)
+	}
+	return &Template{nodes: nodes}, nil
+}
+
+// Execute renders the template with data, typically the dynamic template
+// data of a personalization. Missing values render as empty strings.
+func (t *Template) Execute(w io.Writer, data interface{}) error {
+	var b strings.Builder
+	root := &scope{data: data}
+	root.root = root
+	if err := renderNodes(&b, t.nodes, root); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Render parses and executes a handlebars template
+func Render(text string, data interface{}) (string, error) {
+	t, err := Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// tagKind classifies the content of a mustache
+type tagKind int
+
+const (
+	textToken tagKind = iota
+	exprToken
+	rawToken
+	openToken
+	closeToken
+	elseToken
+)
+
+// token is a run of text or a mustache
+type token struct {
+	kind    tagKind
+	content string
+	pos     int
+}
+
+// lex splits text into text and mustache tokens, dropping comments
+func lex(text string) ([]token, error) {
+	var tokens []token
+	pos := 0
+	for {
+		i := strings.Index(text[pos:], "{{")
+		if i < 0 {
+			if pos < len(text) {
+				tokens = append(tokens, token{kind: textToken, content: text[pos:], pos: pos})
+			}
+			return tokens, nil
+		}
+		if i > 0 {
+			tokens = append(tokens, token{kind: textToken, content: text[pos : pos+i], pos: pos})
+		}
+		start := pos + i
+
+		open, close, kind := "{{", "}}", exprToken
+		switch {
+		case strings.HasPrefix(text[start:], "{{{"):
+			open, close, kind = "{{{", "}}}", rawToken
+		case strings.HasPrefix(text[start:], "{{!--"):
+			open, close = "{{!--", "--}}"
+		case strings.HasPrefix(text[start:], "{{!"):
+			open = "{{!"
+		}
+		end := strings.Index(text[start+len(open):], close)
+		if end < 0 {
+			return nil, fmt.Errorf("template: unclosed %s at offset %d", open, start)
+		}
+		content := strings.TrimSpace(text[start+len(open) : start+len(open)+end])
+		pos = start + len(open) + end + len(close)
+
+		if strings.HasPrefix(open, "{{!") {
+			continue
+		}
+		if kind == exprToken {
+			switch {
+			case strings.HasPrefix(content, "#"):
+				kind, content = openToken, strings.TrimSpace(content[1:])
+			case strings.HasPrefix(content, "/"):
+				kind, content = closeToken, strings.TrimSpace(content[1:])
+			case content == "else" || strings.HasPrefix(content, "else "):
+				kind = elseToken
+			}
+		}
+		if content == "" {
+			return nil, fmt.Errorf("template: empty mustache at offset %d", start)
+		}
+		tokens = append(tokens, token{kind: kind, content: content, pos: start})
+	}
+}
+
+// node is a part of a parsed template
+type node interface {
+	render(b *strings.Builder, s *scope) error
+}
+
+// textNode is literal text
+type textNode string
+
+// exprNode outputs a value or the result of a helper
+type exprNode struct {
+	helper string
+	args   []arg
+	raw    bool
+}
+
+// blockNode is a block helper with its body and else branch
+type blockNode struct {
+	helper  string
+	args    []arg
+	body    []node
+	inverse []node
+}
+
+// arg is a helper argument or expression: a quoted literal or a path
+type arg struct {
+	literal bool
+	value   string
+}
+
+// blockHelpers lists the supported block helpers and their argument count
+var blockHelpers = map[string]int{
+	"if":     1,
+	"unless": 1,
+	"each":   1,
+	"equals": 2,
+}
+
+// parser builds nodes from tokens
+type parser struct {
+	tokens []token
+	next   int
+}
+
+// parseNodes parses nodes until the end of the template or an else or close
+// tag, which is returned
+func (p *parser) parseNodes() ([]node, *token, error) {
+	var nodes []node
+	for p.next < len(p.tokens) {
+		t := &p.tokens[p.next]
+		p.next++
+		switch t.kind {
+		case textToken:
+			nodes = append(nodes, textNode(t.content))
+		case exprToken, rawToken:
+			n, err := parseExpr(t)
+			if err != nil {
+				return nil, nil, err
+			}
+			nodes = append(nodes, n)
+		case openToken:
+			fields, err := splitArgs(t)
+			if err != nil {
+				return nil, nil, err
+			}
+			n, err := p.parseBlock(t, fields, fields[0].value)
+			if err != nil {
+				return nil, nil, err
+			}
+			nodes = append(nodes, n)
+		default:
+			return nodes, t, nil
+		}
+	}
+	return nodes, nil, nil
+}
+
+// parseBlock parses the body of a block opened by fields, up to the close
+// tag named closeName. Chained {{else if ...}} branches are parsed as a
+// nested block closed by the same tag.
+func (p *parser) parseBlock(open *token, fields []arg, closeName string) (*blockNode, error) {
+	helper := fields[0].value
+	count, ok := blockHelpers[helper]
+	if !ok || fields[0].literal {
+		return nil, fmt.Errorf("template: unknown block helper %q at offset %d", helper, open.pos)
+	}
+	if len(fields)-1 != count {
+		return nil, fmt.Errorf("template: %s takes %d arguments, got %d at offset %d", helper, count, len(fields)-1, open.pos)
+	}
+	b := &blockNode{helper: helper, args: fields[1:]}
+
+	body, stop, err := p.parseNodes()
+	if err != nil {
+		return nil, err
+	}
+	b.body = body
+	if stop != nil && stop.kind == elseToken {
+		chained := strings.TrimSpace(strings.TrimPrefix(stop.content, "else"))
+		if chained != "" {
+			fields, err := splitArgs(&token{content: chained, pos: stop.pos})
+			if err != nil {
+				return nil, err
+			}
+			nested, err := p.parseBlock(stop, fields, closeName)
+			if err != nil {
+				return nil, err
+			}
+			b.inverse = []node{nested}
+			return b, nil
+		}
+		if b.inverse, stop, err = p.parseNodes(); err != nil {
+			return nil, err
+		}
+	}
+	if stop == nil {
+		return nil, fmt.Errorf("template: unclosed {{#%s}} at offset %d", closeName, open.pos)
+	}
+	if stop.kind != closeToken || stop.content != closeName {
+		return nil, fmt.Errorf("template: unexpected {{%s}} in {{#%s}} at offset %d", stop.content, closeName, stop.pos)
+	}
+	return b, nil
+}
+
+// parseExpr parses a value expression or a helper call
+func parseExpr(t *token) (*exprNode, error) {
+	fields, err := splitArgs(t)
+	if err != nil {
+		return nil, err
+	}
+	n := &exprNode{raw: t.kind == rawToken}
+	name := fields[0].value
+	if len(fields) == 1 && name != "insert" && name != "formatDate" {
+		n.args = fields
+		return n, nil
+	}
+	n.helper, n.args = name, fields[1:]
+	switch {
+	case fields[0].literal:
+		return nil, fmt.Errorf("template: unexpected literal %q at offset %d", n.helper, t.pos)
+	case n.helper == "insert" && len(n.args) >= 1 && len(n.args) <= 2:
+	case n.helper == "formatDate" && len(n.args) >= 2 && len(n.args) <= 3:
+	case n.helper == "insert" || n.helper == "formatDate":
+		return nil, fmt.Errorf("template: wrong number of arguments to %s at offset %d", n.helper, t.pos)
+	default:
+		return nil, fmt.Errorf("template: unknown helper %q at offset %d", n.helper, t.pos)
+	}
+	return n, nil
+}
+
+// splitArgs splits the content of a mustache into space separated paths
+// and quoted literals
+func splitArgs(t *token) ([]arg, error) {
+	var args []arg
+	s := t.content
+	for {
+		s = strings.TrimLeft(s, " \t\r\n")
+		if s == "" {
+			break
+		}
+		if q := s[0]; q == '"' || q == '\'' {
+			end := strings.IndexByte(s[1:], q)
+			if end < 0 {
+				return nil, fmt.Errorf("template: unterminated string at offset %d", t.pos)
+			}
+			args = append(args, arg{literal: true, value: s[1 : end+1]})
+			s = s[end+2:]
+			continue
+		}
+		end := strings.IndexAny(s, " \t\r\n")
+		if end < 0 {
+			end = len(s)
+		}
+		if strings.ContainsAny(s[:end], "()=") {
+			return nil, fmt.Errorf("template: unsupported expression %q at offset %d", s[:end], t.pos)
+		}
+		args = append(args, arg{value: s[:end]})
+		s = s[end:]
+	}
+	if len(args) == 0 {
+		return nil, errors.New("template: empty mustache")
+	}
+	return args, nil
+}
+
+func (t textNode) render(b *strings.Builder, s *scope) error {
+	b.WriteString(string(t))
+	return nil
+}
+
+func (n *exprNode) render(b *strings.Builder, s *scope) error {
+	var out string
+	switch n.helper {
+	case "":
+		out = stringify(s.eval(n.args[0]))
+	case "insert":
+		out = stringify(s.eval(n.args[0]))
+		if out == "" && len(n.args) == 2 {
+			out = strings.TrimPrefix(stringify(s.eval(n.args[1])), "default=")
+		}
+	case "formatDate":
+		var offset interface{}
+		if len(n.args) == 3 {
+			offset = s.eval(n.args[2])
+		}
+		var err error
+		out, err = formatDate(s.eval(n.args[0]), stringify(s.eval(n.args[1])), offset)
+		if err != nil {
+			return err
+		}
+	}
+	if !n.raw {
+		out = escaper.Replace(out)
+	}
+	b.WriteString(out)
+	return nil
+}
+
+func (n *blockNode) render(b *strings.Builder, s *scope) error {
+	switch n.helper {
+	case "if":
+		if truthy(s.eval(n.args[0])) {
+			return renderNodes(b, n.body, s)
+		}
+	case "unless":
+		if !truthy(s.eval(n.args[0])) {
+			return renderNodes(b, n.body, s)
+		}
+	case "equals":
+		if stringify(s.eval(n.args[0])) == stringify(s.eval(n.args[1])) {
+			return renderNodes(b, n.body, s)
+		}
+	case "each":
+		items := iterate(s.eval(n.args[0]))
+		for i, item := range items {
+			child := &scope{
+				data:   item.value,
+				parent: s,
+				root:   s.root,
+				vars: map[string]interface{}{
+					"index": i,
+					"key":   item.key,
+					"first": i == 0,
+					"last":  i == len(items)-1,
+				},
+			}
+			if err := renderNodes(b, n.body, child); err != nil {
+				return err
+			}
+		}
+		if len(items) > 0 {
+			return nil
+		}
+	}
+	return renderNodes(b, n.inverse, s)
+}
+
+func renderNodes(b *strings.Builder, nodes []node, s *scope) error {
+	for _, n := range nodes {
+		if err := n.render(b, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// escaper escapes the characters handlebars escapes in {{value}}
+var escaper = strings.NewReplacer(
+	"&", "&amp;",
+	"<", "&lt;",
+	">", "&gt;",
+	`"`, "&quot;",
+	"'", "&#x27;",
+	"`", "&#x60;",
+	"=", "&#x3D;",
+)
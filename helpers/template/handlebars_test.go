@@ -0,0 +1,94 @@
+package template
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testData(t *testing.T) map[string]interface{} {
+	var data map[string]interface{}
+	err := json.Unmarshal([]byte(`{
+		"name": "Jane <Doe>",
+		"vip": true,
+		"balance": 0,
+		"code": 1234,
+		"items": [{"title": "Book", "price": 12.5}, {"title": "Pen", "price": 2}],
+		"tags": {"b": "second", "a": "first"},
+		"address": {"city": "Denver"},
+		"empty": []
+	}`), &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestRender(t *testing.T) {
+	tests := map[string]string{
+		"Hi {{name}}!":                        "Hi Jane &lt;Doe&gt;!",
+		"Hi {{{name}}}!":                      "Hi Jane <Doe>!",
+		"{{ address.city }}":                  "Denver",
+		"{{items.1.title}}":                   "Pen",
+		"[{{missing.value}}]":                 "[]",
+		"{{! comment }}x{{!-- {{name}} --}}y": "xy",
+
+		"{{#if vip}}VIP{{else}}regular{{/if}}":                         "VIP",
+		"{{#if balance}}owes{{else}}settled{{/if}}":                    "settled",
+		"{{#if empty}}items{{else if vip}}vip{{else}}none{{/if}}":      "vip",
+		"{{#if empty}}items{{else if balance}}owes{{else}}none{{/if}}": "none",
+		"{{#unless balance}}nothing due{{/unless}}":                    "nothing due",
+		"{{#equals code \"1234\"}}match{{else}}no match{{/equals}}":    "match",
+		"{{#equals code 4321}}match{{else}}no match{{/equals}}":        "no match",
+		"{{#equals address.city 'Denver'}}CO{{/equals}}":               "CO",
+
+		"{{#each items}}{{@index}}:{{title}}={{this.price}}{{#unless @last}}, {{/unless}}{{/each}}": "0:Book=12.5, 1:Pen=2",
+		"{{#each items}}{{title}} for {{../name}}; {{/each}}":                                       "Book for Jane &lt;Doe&gt;; Pen for Jane &lt;Doe&gt;; ",
+		"{{#each tags}}{{@key}}={{this}} {{/each}}":                                                 "a=first b=second ",
+		"{{#each empty}}item{{else}}no items{{/each}}":                                              "no items",
+		"{{#each items}}{{#if @first}}{{@root.address.city}}{{/if}}{{/each}}":                       "Denver",
+
+		"Dear {{insert name 'default=Customer'}}":             "Dear Jane &lt;Doe&gt;",
+		"Dear {{insert nickname 'default=Customer'}}":         "Dear Customer",
+		"Dear {{insert nickname}}":                            "Dear ",
+		"{{formatDate '2020-01-01T23:00:00Z' 'MMM D, YYYY'}}": "Jan 1, 2020",
+	}
+	data := testData(t)
+	for text, expected := range tests {
+		out, err := Render(text, data)
+		if assert.Nil(t, err, text) {
+			assert.Equal(t, expected, out, text)
+		}
+	}
+}
+
+func TestParse_errors(t *testing.T) {
+	tests := []string{
+		"{{name",
+		"{{{name}}",
+		"{{}}",
+		"{{#if vip}}unclosed",
+		"{{#if vip}}x{{/unless}}",
+		"{{#if vip}}x{{else}}y{{else}}z{{/if}}",
+		"{{/if}}",
+		"{{else}}",
+		"{{#with address}}{{city}}{{/with}}",
+		"{{#if}}x{{/if}}",
+		"{{#equals code}}x{{/equals}}",
+		"{{lookup items 0}}",
+		"{{formatDate}}",
+		"{{insert 'a' 'b' 'c'}}",
+		"{{name 'unterminated}}",
+		"{{name (sub expression)}}",
+	}
+	for _, text := range tests {
+		_, err := Parse(text)
+		assert.NotNil(t, err, text)
+	}
+}
+
+func TestExecute_error(t *testing.T) {
+	_, err := Render("{{formatDate date 'YYYY'}}", map[string]interface{}{"date": "soon"})
+	assert.NotNil(t, err)
+}
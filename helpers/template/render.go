@@ -0,0 +1,54 @@
+package template
+
+import (
+	"errors"
+
+	"github.com/sendgrid/sendgrid-go/helpers/mail"
+)
+
+// Rendered is what one personalization of a message receives
+type Rendered struct {
+	Subject string
+	Content []*mail.Content
+}
+
+// RenderPersonalization renders the subject and content of a message as
+// SendGrid would for one of its personalizations. Personalizations with
+// dynamic template data are rendered as handlebars templates, others get
+// the substitutions and sections of the message applied.
+//
+// Content stored in a template referenced by TemplateID is not available
+// locally; fetch it or keep a copy, and render it with Render or
+// Substitute.
+func RenderPersonalization(m *mail.SGMailV3, p *mail.Personalization) (*Rendered, error) {
+	if p == nil {
+		return nil, errors.New("template: a personalization is required")
+	}
+	render := func(text string) (string, error) {
+		if len(p.DynamicTemplateData) > 0 {
+			return Render(text, p.DynamicTemplateData)
+		}
+		return Substitute(text, p.Substitutions, m.Sections), nil
+	}
+
+	subject := m.Subject
+	if p.Subject != "" {
+		subject = p.Subject
+	}
+	var r Rendered
+	var err error
+	if r.Subject, err = render(subject); err != nil {
+		return nil, err
+	}
+	for _, c := range m.Content {
+		if c == nil {
+			continue
+		}
+		value, err := render(c.Value)
+		if err != nil {
+			return nil, err
+		}
+		r.Content = append(r.Content, mail.NewContent(c.Type, value))
+	}
+	return &r, nil
+}
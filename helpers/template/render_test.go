@@ -0,0 +1,40 @@
+package template
+
+import (
+	"testing"
+
+	"github.com/sendgrid/sendgrid-go/helpers/mail"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderPersonalization(t *testing.T) {
+	from := mail.NewEmail("Example User", "test@example.com")
+	to := mail.NewEmail("Jane", "jane@example.com")
+	m := mail.NewSingleEmail(from, "Hello -name-", to, "-greeting-", "<p>-greeting-</p>")
+	m.AddSection("-morning-", "Good morning -name-")
+	m.Personalizations[0].SetSubstitution("-name-", "Jane")
+	m.Personalizations[0].SetSubstitution("-greeting-", "-morning-")
+
+	r, err := RenderPersonalization(m, m.Personalizations[0])
+	if assert.Nil(t, err) {
+		assert.Equal(t, "Hello Jane", r.Subject)
+		assert.Equal(t, "Good morning Jane", r.Content[0].Value)
+		assert.Equal(t, "<p>Good morning Jane</p>", r.Content[1].Value)
+		assert.Equal(t, "text/html", r.Content[1].Type)
+	}
+
+	dynamic := mail.NewSingleEmail(from, "", to, "{{#if vip}}Welcome back{{else}}Hello{{/if}} {{first}}", "<b>{{first}}</b>")
+	p := dynamic.Personalizations[0]
+	p.Subject = "For {{first}}"
+	p.SetDynamicTemplateData("first", "Jane & co")
+	p.SetDynamicTemplateData("vip", true)
+	r, err = RenderPersonalization(dynamic, p)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "For Jane &amp; co", r.Subject)
+		assert.Equal(t, "Welcome back Jane &amp; co", r.Content[0].Value)
+		assert.Equal(t, "<b>Jane &amp; co</b>", r.Content[1].Value)
+	}
+
+	_, err = RenderPersonalization(m, nil)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,196 @@
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// scope is the context a template is rendered in. Each iteration of an
+// each block gets a child scope with the @index, @key, @first and @last
+// variables.
+type scope struct {
+	data   interface{}
+	parent *scope
+	root   *scope
+	vars   map[string]interface{}
+}
+
+// eval returns the value of a literal or of a path such as name,
+// user.name, this, ../name, @index or @root.name
+func (s *scope) eval(a arg) interface{} {
+	if a.literal {
+		return a.value
+	}
+	path := a.value
+	if _, err := strconv.ParseFloat(path, 64); err == nil {
+		return json.Number(path)
+	}
+	switch path {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "null", "undefined":
+		return nil
+	}
+
+	for strings.HasPrefix(path, "../") {
+		path = path[len("../"):]
+		if s.parent != nil {
+			s = s.parent
+		}
+	}
+	if strings.HasPrefix(path, "@") {
+		name := path[1:]
+		rest := ""
+		if i := strings.IndexByte(name, '.'); i >= 0 {
+			name, rest = name[:i], name[i+1:]
+		}
+		if name == "root" {
+			return lookup(s.root.data, rest)
+		}
+		return lookup(s.vars[name], rest)
+	}
+	switch {
+	case path == "this" || path == ".":
+		path = ""
+	case strings.HasPrefix(path, "this."):
+		path = path[len("this."):]
+	case strings.HasPrefix(path, "./"):
+		path = path[len("./"):]
+	}
+	return lookup(s.data, path)
+}
+
+// lookup follows a dotted path through maps, slices and structs
+func lookup(v interface{}, path string) interface{} {
+	if path == "" {
+		return v
+	}
+	for _, key := range strings.Split(path, ".") {
+		rv := reflect.ValueOf(v)
+		for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+			if rv.IsNil() {
+				return nil
+			}
+			rv = rv.Elem()
+		}
+		switch rv.Kind() {
+		case reflect.Map:
+			if rv.Type().Key().Kind() != reflect.String {
+				return nil
+			}
+			e := rv.MapIndex(reflect.ValueOf(key).Convert(rv.Type().Key()))
+			if !e.IsValid() {
+				return nil
+			}
+			v = e.Interface()
+		case reflect.Slice, reflect.Array:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= rv.Len() {
+				return nil
+			}
+			v = rv.Index(i).Interface()
+		case reflect.Struct:
+			f := rv.FieldByName(key)
+			if !f.IsValid() || !f.CanInterface() {
+				return nil
+			}
+			v = f.Interface()
+		default:
+			return nil
+		}
+	}
+	return v
+}
+
+// truthy reports whether if blocks render their body for v: false, nil,
+// empty strings, zero and empty lists are falsy
+func truthy(v interface{}) bool {
+	if v == nil {
+		return false
+	}
+	if n, ok := v.(json.Number); ok {
+		f, err := n.Float64()
+		return err != nil || f != 0
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Bool:
+		return rv.Bool()
+	case reflect.String, reflect.Slice, reflect.Array:
+		return rv.Len() > 0
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int() != 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint() != 0
+	case reflect.Float32, reflect.Float64:
+		return rv.Float() != 0
+	case reflect.Ptr, reflect.Interface, reflect.Map:
+		return !rv.IsNil()
+	}
+	return true
+}
+
+// stringify formats v like handlebars does: nothing for nil, numbers
+// without exponents and lists joined with commas
+func stringify(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case fmt.Stringer:
+		return v.String()
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		items := make([]string, rv.Len())
+		for i := range items {
+			items[i] = stringify(rv.Index(i).Interface())
+		}
+		return strings.Join(items, ",")
+	}
+	return fmt.Sprint(v)
+}
+
+// item is an element iterated over by an each block
+type item struct {
+	key   interface{}
+	value interface{}
+}
+
+// iterate lists the elements of a slice, or the values of a map in key
+// order
+func iterate(v interface{}) []item {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		items := make([]item, rv.Len())
+		for i := range items {
+			items[i] = item{key: i, value: rv.Index(i).Interface()}
+		}
+		return items
+	case reflect.Map:
+		keys := rv.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		items := make([]item, len(keys))
+		for i, k := range keys {
+			items[i] = item{key: k.Interface(), value: rv.MapIndex(k).Interface()}
+		}
+		return items
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+// Package template renders messages locally the way SendGrid renders them
+// when sending: legacy substitutions and sections, and the handlebars
+// subset supported by dynamic templates. It makes what recipients see
+// testable without sending anything.
+package template
+
+import (
+	"sort"
+	"strings"
+)
+
+// Substitute applies legacy substitutions and sections to text. Keys are
+// replaced verbatim, including their delimiters, e.g. "-name-". Section
+// tags usually appear as substitution values; once sections are expanded,
+// substitutions are applied again to the tags the sections contain.
+func Substitute(text string, substitutions, sections map[string]string) string {
+	text = replacer(substitutions).Replace(text)
+	if len(sections) == 0 {
+		return text
+	}
+	text = replacer(sections).Replace(text)
+	return replacer(substitutions).Replace(text)
+}
+
+// replacer replaces the keys of m by their values, preferring the longest
+// key when several match at the same position
+func replacer(m map[string]string) *strings.Replacer {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		if k != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) > len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+	oldnew := make([]string, 0, 2*len(keys))
+	for _, k := range keys {
+		oldnew = append(oldnew, k, m[k])
+	}
+	return strings.NewReplacer(oldnew...)
+}
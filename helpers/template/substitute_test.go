@@ -0,0 +1,22 @@
+package template
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubstitute(t *testing.T) {
+	subs := map[string]string{
+		"-name-":     "Jane",
+		"-name_full": "Jane Doe",
+		"-greeting-": "-morning-",
+	}
+	sections := map[string]string{
+		"-morning-": "Good morning -name-",
+	}
+	text := "-greeting-, -name_full (-name-) -unknown-"
+	assert.Equal(t, "Good morning Jane, Jane Doe (Jane) -unknown-", Substitute(text, subs, sections))
+	assert.Equal(t, "-morning-, Jane Doe (Jane) -unknown-", Substitute(text, subs, nil))
+	assert.Equal(t, text, Substitute(text, nil, nil))
+}
@@ -0,0 +1,220 @@
+// Package webhook receives the events Twilio SendGrid posts to the Event
+// Webhook, see https://sendgrid.com/docs/for-developers/tracking-events/event/
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// EventType is the value of the event field of an event
+type EventType string
+
+// Event types posted by the Event Webhook
+const (
+	Processed        EventType = "processed"
+	Dropped          EventType = "dropped"
+	Delivered        EventType = "delivered"
+	Deferred         EventType = "deferred"
+	Bounce           EventType = "bounce"
+	Open             EventType = "open"
+	Click            EventType = "click"
+	SpamReport       EventType = "spamreport"
+	Unsubscribe      EventType = "unsubscribe"
+	GroupUnsubscribe EventType = "group_unsubscribe"
+	GroupResubscribe EventType = "group_resubscribe"
+)
+
+// Event is one of the typed events, e.g. *DeliveredEvent, or an
+// *UnknownEvent for event types added after this package
+type Event interface {
+	Base() *EventBase
+}
+
+// EventBase holds the fields common to every event
+type EventBase struct {
+	Email       string    `json:"email"`
+	Timestamp   int64     `json:"timestamp"`
+	Event       EventType `json:"event"`
+	SGEventID   string    `json:"sg_event_id"`
+	SGMessageID string    `json:"sg_message_id"`
+	SMTPID      string    `json:"smtp-id,omitempty"`
+	// Categories are the categories of the message, SendGrid posts a
+	// single category as a string
+	Categories Categories `json:"category,omitempty"`
+	ASMGroupID int        `json:"asm_group_id,omitempty"`
+
+	MarketingCampaignID      int    `json:"marketing_campaign_id,omitempty"`
+	MarketingCampaignName    string `json:"marketing_campaign_name,omitempty"`
+	MarketingCampaignVersion string `json:"marketing_campaign_version,omitempty"`
+	MarketingCampaignSplitID int    `json:"marketing_campaign_split_id,omitempty"`
+
+	// CustomArgs are the custom args (unique args) of the message, posted
+	// as top level fields of the event
+	CustomArgs map[string]string `json:"-"`
+}
+
+// Base returns the common fields of the event
+func (e *EventBase) Base() *EventBase {
+	return e
+}
+
+// Time returns the time the event happened at
+func (e *EventBase) Time() time.Time {
+	return time.Unix(e.Timestamp, 0)
+}
+
+// Categories is a list of categories
+type Categories []string
+
+// UnmarshalJSON accepts a single category as well as a list
+func (c *Categories) UnmarshalJSON(b []byte) error {
+	var category string
+	if err := json.Unmarshal(b, &category); err == nil {
+		*c = Categories{category}
+		return nil
+	}
+	var categories []string
+	if err := json.Unmarshal(b, &categories); err != nil {
+		return err
+	}
+	*c = categories
+	return nil
+}
+
+// Pool is the IP pool a message was sent from
+type Pool struct {
+	Name string `json:"name"`
+	ID   int    `json:"id"`
+}
+
+// ProcessedEvent is posted when a message was received and is ready to be
+// delivered
+type ProcessedEvent struct {
+	EventBase
+	SendAt int64 `json:"send_at,omitempty"`
+	Pool   *Pool `json:"pool,omitempty"`
+}
+
+// DroppedEvent is posted when a message was not delivered, e.g. because
+// the recipient is suppressed
+type DroppedEvent struct {
+	EventBase
+	Reason string `json:"reason"`
+	Status string `json:"status"`
+}
+
+// DeliveredEvent is posted when the receiving server accepted a message
+type DeliveredEvent struct {
+	EventBase
+	IP       string `json:"ip"`
+	Response string `json:"response"`
+	TLS      int    `json:"tls"`
+	CertErr  int    `json:"cert_err"`
+}
+
+// DeferredEvent is posted when the receiving server temporarily rejected
+// a message
+type DeferredEvent struct {
+	EventBase
+	IP       string `json:"ip"`
+	Response string `json:"response"`
+	Attempt  string `json:"attempt"`
+	TLS      int    `json:"tls"`
+	CertErr  int    `json:"cert_err"`
+}
+
+// BounceEvent is posted when the receiving server permanently rejected a
+// message. Type is "bounce" or "blocked".
+type BounceEvent struct {
+	EventBase
+	IP             string `json:"ip"`
+	Reason         string `json:"reason"`
+	Status         string `json:"status"`
+	Type           string `json:"type"`
+	Classification string `json:"bounce_classification,omitempty"`
+	TLS            int    `json:"tls"`
+	CertErr        int    `json:"cert_err"`
+}
+
+// OpenEvent is posted when a recipient opened a message
+type OpenEvent struct {
+	EventBase
+	IP        string `json:"ip"`
+	UserAgent string `json:"useragent"`
+	// MachineOpen is set for opens by privacy proxies such as Apple Mail
+	// Privacy Protection
+	MachineOpen bool `json:"sg_machine_open"`
+}
+
+// URLOffset locates a link in a message
+type URLOffset struct {
+	Index int    `json:"index"`
+	Type  string `json:"type"`
+}
+
+// ClickEvent is posted when a recipient clicked a link in a message
+type ClickEvent struct {
+	EventBase
+	IP        string     `json:"ip"`
+	UserAgent string     `json:"useragent"`
+	URL       string     `json:"url"`
+	URLOffset *URLOffset `json:"url_offset,omitempty"`
+}
+
+// SpamReportEvent is posted when a recipient marked a message as spam
+type SpamReportEvent struct {
+	EventBase
+}
+
+// UnsubscribeEvent is posted when a recipient unsubscribed from all
+// messages
+type UnsubscribeEvent struct {
+	EventBase
+}
+
+// GroupUnsubscribeEvent is posted when a recipient unsubscribed from the
+// ASMGroupID group
+type GroupUnsubscribeEvent struct {
+	EventBase
+	IP        string `json:"ip"`
+	UserAgent string `json:"useragent"`
+	URL       string `json:"url,omitempty"`
+}
+
+// GroupResubscribeEvent is posted when a recipient subscribed again to the
+// ASMGroupID group
+type GroupResubscribeEvent struct {
+	EventBase
+	IP        string `json:"ip"`
+	UserAgent string `json:"useragent"`
+	URL       string `json:"url,omitempty"`
+}
+
+// UnknownEvent is an event of a type this package does not know. Raw
+// holds the JSON of the event.
+type UnknownEvent struct {
+	EventBase
+	Raw json.RawMessage `json:"-"`
+}
+
+// InvalidEvent takes the place of an event of a batch that could not be
+// decoded. Raw holds the JSON of the event, Err why it was rejected and
+// EventBase the common fields that could be read.
+type InvalidEvent struct {
+	EventBase
+	// Index is the position of the event in the batch
+	Index int             `json:"-"`
+	Raw   json.RawMessage `json:"-"`
+	Err   error           `json:"-"`
+}
+
+func (e *InvalidEvent) Error() string {
+	return fmt.Sprintf("webhook: invalid event %d: %v", e.Index, e.Err)
+}
+
+// Unwrap returns the error the event was rejected with
+func (e *InvalidEvent) Unwrap() error {
+	return e.Err
+}
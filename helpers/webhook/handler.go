@@ -0,0 +1,44 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// DefaultMaxBodySize limits the size of the requests Handler and Verifier
+// read, well above the size of the batches SendGrid posts
+const DefaultMaxBodySize = 10 << 20
+
+// Handler returns an http.Handler decoding Event Webhook requests and
+// passing each batch of events to callback with the context of the
+// request. Events that cannot be decoded are passed as *InvalidEvent
+// rather than failing the batch, and requests over DefaultMaxBodySize get
+// a 413.
+func Handler(callback func(context.Context, []Event) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		events, err := ParseEvents(http.MaxBytesReader(w, r.Body, DefaultMaxBodySize))
+		var invalid *InvalidEventsError
+		var tooLarge *http.MaxBytesError
+		switch {
+		case err == nil || errors.As(err, &invalid):
+		case errors.As(err, &tooLarge):
+			http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+			return
+		default:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := callback(r.Context(), events); err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}
@@ -0,0 +1,92 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sampleRequest(t *testing.T) *http.Request {
+	file, err := os.Open("./sample_data/events.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { file.Close() })
+	request := httptest.NewRequest("POST", "/events", file)
+	request.Header.Set("Content-Type", "application/json")
+	return request
+}
+
+func TestHandler(t *testing.T) {
+	batches := 0
+	var received []Event
+	handler := Handler(func(ctx context.Context, events []Event) error {
+		assert.NotNil(t, ctx)
+		batches++
+		received = events
+		return nil
+	})
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, sampleRequest(t))
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, 1, batches, "The callback should be invoked once per batch")
+	assert.Equal(t, 12, len(received))
+}
+
+func TestHandler_errors(t *testing.T) {
+	called := false
+	handler := Handler(func(ctx context.Context, events []Event) error {
+		called = true
+		return errors.New("queue full")
+	})
+
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequest("GET", "/events", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, response.Code)
+	assert.Equal(t, "POST", response.Header().Get("Allow"))
+
+	response = httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequest("POST", "/events", strings.NewReader("not json")))
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	assert.False(t, called)
+
+	response = httptest.NewRecorder()
+	handler.ServeHTTP(response, sampleRequest(t))
+	assert.Equal(t, http.StatusInternalServerError, response.Code, "SendGrid should retry batches the callback failed on")
+	assert.True(t, called)
+}
+
+func TestHandler_invalidEvents(t *testing.T) {
+	var received []Event
+	handler := Handler(func(ctx context.Context, events []Event) error {
+		received = events
+		return nil
+	})
+	body := `[{"email": "a@example.com", "event": "delivered"}, {"event": "delivered", "tls": "yes"}]`
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequest("POST", "/events", strings.NewReader(body)))
+	assert.Equal(t, http.StatusOK, response.Code, "An invalid event should not make SendGrid retry the batch")
+	if assert.Equal(t, 2, len(received)) {
+		assert.IsType(t, &DeliveredEvent{}, received[0])
+		assert.IsType(t, &InvalidEvent{}, received[1])
+	}
+}
+
+func TestHandler_tooLarge(t *testing.T) {
+	called := false
+	handler := Handler(func(ctx context.Context, events []Event) error {
+		called = true
+		return nil
+	})
+	body := `[{"event": "delivered", "email": "` + strings.Repeat("a", DefaultMaxBodySize) + `"}]`
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequest("POST", "/events", strings.NewReader(body)))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, response.Code)
+	assert.False(t, called)
+}
@@ -0,0 +1,164 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// newEvent returns an empty event of type t
+func newEvent(t EventType) Event {
+	switch t {
+	case Processed:
+		return &ProcessedEvent{}
+	case Dropped:
+		return &DroppedEvent{}
+	case Delivered:
+		return &DeliveredEvent{}
+	case Deferred:
+		return &DeferredEvent{}
+	case Bounce:
+		return &BounceEvent{}
+	case Open:
+		return &OpenEvent{}
+	case Click:
+		return &ClickEvent{}
+	case SpamReport:
+		return &SpamReportEvent{}
+	case Unsubscribe:
+		return &UnsubscribeEvent{}
+	case GroupUnsubscribe:
+		return &GroupUnsubscribeEvent{}
+	case GroupResubscribe:
+		return &GroupResubscribeEvent{}
+	}
+	return &UnknownEvent{}
+}
+
+// reservedFields are fields SendGrid posts that are neither modeled by
+// the events nor custom args
+var reservedFields = map[string]bool{
+	"post_type":           true,
+	"newsletter":          true,
+	"mc_stats":            true,
+	"phase_id":            true,
+	"singlesend_id":       true,
+	"singlesend_name":     true,
+	"template_id":         true,
+	"template_name":       true,
+	"template_version_id": true,
+	"sg_template_id":      true,
+	"sg_template_name":    true,
+}
+
+// knownFields caches the JSON fields of event types
+var knownFields sync.Map
+
+// InvalidEventsError is returned by ParseEvents along with the batch when
+// some of its events could not be decoded
+type InvalidEventsError struct {
+	Events []*InvalidEvent
+}
+
+func (e *InvalidEventsError) Error() string {
+	if len(e.Events) == 1 {
+		return e.Events[0].Error()
+	}
+	return fmt.Sprintf("%v (and %d more invalid events)", e.Events[0], len(e.Events)-1)
+}
+
+// ParseEvents decodes the JSON array of events posted by the Event
+// Webhook. Fields of an event that are not part of its type are custom
+// args.
+//
+// Each event is decoded on its own: an event that cannot be decoded is
+// returned in its place as an *InvalidEvent, and the batch is returned
+// with an *InvalidEventsError listing them. Any other error means the body
+// is not a JSON array.
+func ParseEvents(r io.Reader) ([]Event, error) {
+	var raws []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raws); err != nil {
+		return nil, fmt.Errorf("webhook: invalid events: %w", err)
+	}
+	events := make([]Event, 0, len(raws))
+	var invalid []*InvalidEvent
+	for i, raw := range raws {
+		event, err := parseEvent(raw)
+		if err != nil {
+			e := &InvalidEvent{Index: i, Raw: raw, Err: err}
+			json.Unmarshal(raw, &e.EventBase)
+			invalid = append(invalid, e)
+			event = e
+		}
+		events = append(events, event)
+	}
+	if len(invalid) > 0 {
+		return events, &InvalidEventsError{Events: invalid}
+	}
+	return events, nil
+}
+
+// parseEvent decodes a single event
+func parseEvent(raw json.RawMessage) (Event, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	var eventType EventType
+	if err := json.Unmarshal(fields["event"], &eventType); err != nil {
+		return nil, fmt.Errorf("invalid event type: %v", err)
+	}
+
+	event := newEvent(eventType)
+	if err := json.Unmarshal(raw, event); err != nil {
+		return nil, err
+	}
+	if unknown, ok := event.(*UnknownEvent); ok {
+		unknown.Raw = raw
+	}
+
+	t := reflect.TypeOf(event).Elem()
+	known, ok := knownFields.Load(t)
+	if !ok {
+		known, _ = knownFields.LoadOrStore(t, jsonFields(t))
+	}
+	for name, value := range fields {
+		if known.(map[string]bool)[name] || reservedFields[name] {
+			continue
+		}
+		base := event.Base()
+		if base.CustomArgs == nil {
+			base.CustomArgs = make(map[string]string)
+		}
+		var s string
+		if err := json.Unmarshal(value, &s); err != nil {
+			// custom args are strings, keep other values as JSON
+			s = string(value)
+		}
+		base.CustomArgs[name] = s
+	}
+	return event, nil
+}
+
+// jsonFields returns the JSON field names of struct type t, including
+// the fields of embedded structs
+func jsonFields(t reflect.Type) map[string]bool {
+	fields := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			for name := range jsonFields(f.Type) {
+				fields[name] = true
+			}
+			continue
+		}
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			fields[name] = true
+		}
+	}
+	return fields
+}
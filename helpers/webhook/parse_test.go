@@ -0,0 +1,133 @@
+package webhook
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sampleEvents(t *testing.T) []Event {
+	file, err := os.Open("./sample_data/events.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	events, err := ParseEvents(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return events
+}
+
+func TestParseEvents(t *testing.T) {
+	events := sampleEvents(t)
+	if !assert.Equal(t, 12, len(events)) {
+		return
+	}
+	for _, event := range events {
+		base := event.Base()
+		assert.Equal(t, "example@test.com", base.Email)
+		assert.NotEqual(t, "", base.SGEventID)
+		assert.True(t, strings.HasPrefix(base.SGMessageID, "14c5d75ce93.dfd.64b469"))
+		assert.Equal(t, time.Date(2017, 12, 15, 0, 59, 29, 0, time.UTC), base.Time().UTC())
+	}
+
+	processed := events[0].(*ProcessedEvent)
+	assert.Equal(t, Processed, processed.Event)
+	assert.Equal(t, "<14c5d75ce93.dfd.64b469@ismtpd-555>", processed.SMTPID)
+	assert.Equal(t, Categories{"cat facts"}, processed.Categories)
+	assert.Equal(t, &Pool{Name: "new_MY_test", ID: 210}, processed.Pool)
+	assert.Equal(t, map[string]string{"order_id": "1234"}, processed.CustomArgs)
+
+	dropped := events[1].(*DroppedEvent)
+	assert.Equal(t, Categories{"cat facts", "weekly"}, dropped.Categories)
+	assert.Equal(t, "Bounced Address", dropped.Reason)
+	assert.Equal(t, "5.0.0", dropped.Status)
+	assert.Nil(t, dropped.CustomArgs)
+
+	delivered := events[2].(*DeliveredEvent)
+	assert.Equal(t, "250 OK", delivered.Response)
+	assert.Equal(t, "168.1.1.1", delivered.IP)
+	assert.Equal(t, 1, delivered.TLS)
+
+	deferred := events[3].(*DeferredEvent)
+	assert.Equal(t, "5", deferred.Attempt)
+	assert.Equal(t, "400 try again later", deferred.Response)
+
+	bounce := events[4].(*BounceEvent)
+	assert.Equal(t, "bounce", bounce.Type)
+	assert.Equal(t, "Invalid Address", bounce.Classification)
+	assert.Equal(t, "500 unknown recipient", bounce.Reason)
+
+	open := events[5].(*OpenEvent)
+	assert.True(t, open.MachineOpen)
+	assert.Equal(t, "255.255.255.255", open.IP)
+	assert.True(t, strings.HasPrefix(open.UserAgent, "Mozilla/4.0"))
+
+	click := events[6].(*ClickEvent)
+	assert.Equal(t, "http://www.sendgrid.com/", click.URL)
+	assert.Equal(t, &URLOffset{Index: 0, Type: "html"}, click.URLOffset)
+
+	assert.Equal(t, SpamReport, events[7].(*SpamReportEvent).Event)
+	assert.Equal(t, Unsubscribe, events[8].(*UnsubscribeEvent).Event)
+
+	unsubscribe := events[9].(*GroupUnsubscribeEvent)
+	assert.Equal(t, 10, unsubscribe.ASMGroupID)
+	assert.Equal(t, "http://www.sendgrid.com/", unsubscribe.URL)
+	assert.Nil(t, unsubscribe.CustomArgs)
+	assert.Equal(t, 10, events[10].(*GroupResubscribeEvent).ASMGroupID)
+
+	unknown := events[11].(*UnknownEvent)
+	assert.Equal(t, EventType("machine_learning"), unknown.Event)
+	assert.Equal(t, map[string]string{"score": "0.5"}, unknown.CustomArgs)
+	assert.Contains(t, string(unknown.Raw), `"score": 0.5`)
+}
+
+func TestParseEvents_errors(t *testing.T) {
+	tests := []string{
+		"",
+		"{}",
+		"[1]",
+		`[{"email": "example@test.com"}]`,
+		`[{"event": "delivered", "tls": "yes"}]`,
+		`[{"event": "processed", "category": 1}]`,
+	}
+	for _, body := range tests {
+		_, err := ParseEvents(strings.NewReader(body))
+		assert.NotNil(t, err, body)
+	}
+
+	events, err := ParseEvents(strings.NewReader("[]"))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(events))
+}
+
+func TestParseEvents_invalidEvents(t *testing.T) {
+	body := `[
+		{"email": "a@example.com", "event": "delivered", "timestamp": 1513299569},
+		{"email": "b@example.com", "event": "delivered", "tls": "yes"},
+		{"email": "c@example.com"},
+		{"email": "d@example.com", "event": "open", "timestamp": 1513299569}
+	]`
+	events, err := ParseEvents(strings.NewReader(body))
+	var invalid *InvalidEventsError
+	if !assert.True(t, errors.As(err, &invalid), "Invalid events should not reject the batch") {
+		return
+	}
+	assert.Equal(t, 2, len(invalid.Events))
+	assert.Contains(t, err.Error(), "webhook: invalid event 1:")
+	if assert.Equal(t, 4, len(events)) {
+		assert.Equal(t, "a@example.com", events[0].(*DeliveredEvent).Email)
+		bad := events[1].(*InvalidEvent)
+		assert.Equal(t, 1, bad.Index)
+		assert.Equal(t, "b@example.com", bad.Email)
+		assert.Contains(t, string(bad.Raw), `"tls": "yes"`)
+		assert.Equal(t, invalid.Events[0], bad)
+		assert.Equal(t, 2, events[2].(*InvalidEvent).Index)
+		assert.Equal(t, "d@example.com", events[3].(*OpenEvent).Email)
+	}
+}
@@ -0,0 +1,166 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Headers of signed Event Webhook requests
+const (
+	SignatureHeader = "X-Twilio-Email-Event-Webhook-Signature"
+	TimestampHeader = "X-Twilio-Email-Event-Webhook-Timestamp"
+)
+
+// DefaultTolerance is how far the timestamp of a request may be from the
+// current time
+const DefaultTolerance = 10 * time.Minute
+
+var (
+	// ErrInvalidSignature is returned for requests without a valid
+	// signature
+	ErrInvalidSignature = errors.New("webhook: invalid signature")
+	// ErrStaleTimestamp is returned for requests signed outside the
+	// tolerance of the verifier, e.g. replayed requests
+	ErrStaleTimestamp = errors.New("webhook: stale timestamp")
+	// ErrNoPublicKey is returned by a Verifier without a PublicKey
+	ErrNoPublicKey = errors.New("webhook: no public key to verify signatures with")
+	// ErrBodyTooLarge is returned for requests larger than the MaxBodySize
+	// of the verifier
+	ErrBodyTooLarge = errors.New("webhook: request body too large")
+)
+
+// Verifier verifies the ECDSA signatures of signed Event Webhook requests
+type Verifier struct {
+	PublicKey *ecdsa.PublicKey
+	// Tolerance is DefaultTolerance if zero. Use a negative value to
+	// accept any timestamp.
+	Tolerance time.Duration
+	// MaxBodySize limits the size of the requests VerifyRequest reads,
+	// DefaultMaxBodySize if zero
+	MaxBodySize int64
+
+	now func() time.Time
+}
+
+// ParsePublicKey parses the base64 public key returned by
+// /v3/user/webhooks/event/settings/signed
+func ParsePublicKey(key string) (*ecdsa.PublicKey, error) {
+	der, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("webhook: invalid public key: %v", err)
+	}
+	pub, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("webhook: invalid public key: %v", err)
+	}
+	ecdsaKey, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("webhook: public key is a %T, not an ECDSA key", pub)
+	}
+	return ecdsaKey, nil
+}
+
+// NewVerifier returns a verifier for the base64 public key returned by
+// /v3/user/webhooks/event/settings/signed
+func NewVerifier(key string) (*Verifier, error) {
+	pub, err := ParsePublicKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return &Verifier{PublicKey: pub}, nil
+}
+
+// Verify checks the base64 signature of payload, the raw request body,
+// signed along with timestamp
+func (v *Verifier) Verify(payload []byte, signature, timestamp string) error {
+	if v.PublicKey == nil {
+		return ErrNoPublicKey
+	}
+	if signature == "" || timestamp == "" {
+		return ErrInvalidSignature
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	hash := sha256.New()
+	hash.Write([]byte(timestamp))
+	hash.Write(payload)
+	if !ecdsa.VerifyASN1(v.PublicKey, hash.Sum(nil), sig) {
+		return ErrInvalidSignature
+	}
+
+	tolerance := v.Tolerance
+	if tolerance == 0 {
+		tolerance = DefaultTolerance
+	}
+	if tolerance < 0 {
+		return nil
+	}
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrStaleTimestamp
+	}
+	now := time.Now
+	if v.now != nil {
+		now = v.now
+	}
+	age := now().Sub(time.Unix(seconds, 0))
+	if age > tolerance || age < -tolerance {
+		return ErrStaleTimestamp
+	}
+	return nil
+}
+
+// VerifyRequest checks the signature of request, leaving its body to be
+// read again. Bodies over MaxBodySize are not read and ErrBodyTooLarge is
+// returned.
+func (v *Verifier) VerifyRequest(request *http.Request) error {
+	return v.verifyRequest(nil, request)
+}
+
+// verifyRequest is VerifyRequest, with w passed to http.MaxBytesReader
+func (v *Verifier) verifyRequest(w http.ResponseWriter, request *http.Request) error {
+	max := v.MaxBodySize
+	if max <= 0 {
+		max = DefaultMaxBodySize
+	}
+	payload, err := ioutil.ReadAll(http.MaxBytesReader(w, request.Body, max))
+	request.Body.Close()
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return ErrBodyTooLarge
+	}
+	if err != nil {
+		return fmt.Errorf("webhook: reading body: %v", err)
+	}
+	request.Body = ioutil.NopCloser(bytes.NewReader(payload))
+	return v.Verify(payload, request.Header.Get(SignatureHeader), request.Header.Get(TimestampHeader))
+}
+
+// Middleware passes the requests with a valid signature to next and
+// responds 403 Forbidden to the others, 413 Request Entity Too Large to
+// requests over MaxBodySize and 500 if the verifier has no PublicKey
+func (v *Verifier) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch err := v.verifyRequest(w, r); err {
+		case nil:
+			next.ServeHTTP(w, r)
+		case ErrBodyTooLarge:
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		case ErrNoPublicKey:
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		default:
+			http.Error(w, err.Error(), http.StatusForbidden)
+		}
+	})
+}
@@ -0,0 +1,184 @@
+package webhook
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// signer signs payloads the way the Event Webhook does
+type signer struct {
+	key *ecdsa.PrivateKey
+}
+
+func newSigner(t *testing.T) *signer {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &signer{key: key}
+}
+
+// publicKey returns the public key as /v3/user/webhooks/event/settings/signed
+// does
+func (s *signer) publicKey(t *testing.T) string {
+	der, err := x509.MarshalPKIXPublicKey(&s.key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(der)
+}
+
+func (s *signer) sign(t *testing.T, timestamp, payload string) string {
+	hash := sha256.Sum256([]byte(timestamp + payload))
+	sig, err := ecdsa.SignASN1(rand.Reader, s.key, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(sig)
+}
+
+func (s *signer) request(t *testing.T, at time.Time, payload string) *http.Request {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	request := httptest.NewRequest("POST", "/events", strings.NewReader(payload))
+	request.Header.Set(SignatureHeader, s.sign(t, timestamp, payload))
+	request.Header.Set(TimestampHeader, timestamp)
+	return request
+}
+
+func TestVerifier(t *testing.T) {
+	s := newSigner(t)
+	v, err := NewVerifier(s.publicKey(t))
+	if !assert.Nil(t, err) {
+		return
+	}
+	payload := `[{"email":"example@test.com","event":"processed"}]` + "\r\n"
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	signature := s.sign(t, timestamp, payload)
+
+	assert.Nil(t, v.Verify([]byte(payload), signature, timestamp))
+	assert.Equal(t, ErrInvalidSignature, v.Verify([]byte(payload+" "), signature, timestamp), "The raw body must be verified")
+	assert.Equal(t, ErrInvalidSignature, v.Verify([]byte(payload), signature, timestamp+"0"))
+	assert.Equal(t, ErrInvalidSignature, v.Verify([]byte(payload), "not base64!", timestamp))
+	assert.Equal(t, ErrInvalidSignature, v.Verify([]byte(payload), "", timestamp))
+	assert.Equal(t, ErrInvalidSignature, v.Verify([]byte(payload), signature, ""))
+
+	other, _ := NewVerifier(newSigner(t).publicKey(t))
+	assert.Equal(t, ErrInvalidSignature, other.Verify([]byte(payload), signature, timestamp))
+}
+
+func TestVerifier_timestamp(t *testing.T) {
+	s := newSigner(t)
+	v, _ := NewVerifier(s.publicKey(t))
+	now := time.Unix(1600000000, 0)
+	v.now = func() time.Time { return now }
+	payload := "[]"
+
+	verify := func(at time.Time) error {
+		timestamp := strconv.FormatInt(at.Unix(), 10)
+		return v.Verify([]byte(payload), s.sign(t, timestamp, payload), timestamp)
+	}
+	assert.Nil(t, verify(now.Add(-DefaultTolerance)))
+	assert.Equal(t, ErrStaleTimestamp, verify(now.Add(-DefaultTolerance-time.Second)))
+	assert.Equal(t, ErrStaleTimestamp, verify(now.Add(DefaultTolerance+time.Second)))
+
+	v.Tolerance = time.Minute
+	assert.Equal(t, ErrStaleTimestamp, verify(now.Add(-2*time.Minute)))
+
+	v.Tolerance = -1
+	assert.Nil(t, verify(now.Add(-24*time.Hour)))
+
+	v.Tolerance = 0
+	assert.Equal(t, ErrStaleTimestamp, v.Verify([]byte(payload), s.sign(t, "yesterday", payload), "yesterday"))
+}
+
+func TestParsePublicKey_errors(t *testing.T) {
+	_, err := ParsePublicKey("not base64!")
+	assert.NotNil(t, err)
+
+	_, err = ParsePublicKey(base64.StdEncoding.EncodeToString([]byte("not a key")))
+	assert.NotNil(t, err)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, _ := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	_, err = NewVerifier(base64.StdEncoding.EncodeToString(der))
+	assert.NotNil(t, err)
+}
+
+func TestVerifier_middleware(t *testing.T) {
+	s := newSigner(t)
+	v, _ := NewVerifier(s.publicKey(t))
+	var received []Event
+	handler := v.Middleware(Handler(func(ctx context.Context, events []Event) error {
+		received = events
+		return nil
+	}))
+	payload := `[{"email":"example@test.com","event":"delivered","sg_event_id":"a","sg_message_id":"b"}]`
+
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, s.request(t, time.Now(), payload))
+	assert.Equal(t, http.StatusOK, response.Code)
+	if assert.Equal(t, 1, len(received), "The body should be readable after verification") {
+		assert.Equal(t, "b", received[0].Base().SGMessageID)
+	}
+
+	received = nil
+	response = httptest.NewRecorder()
+	handler.ServeHTTP(response, s.request(t, time.Now().Add(-time.Hour), payload))
+	assert.Equal(t, http.StatusForbidden, response.Code)
+
+	request := s.request(t, time.Now(), payload)
+	request.Body = ioutil.NopCloser(strings.NewReader(strings.Replace(payload, "delivered", "bounce", 1)))
+	response = httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusForbidden, response.Code)
+
+	request = httptest.NewRequest("POST", "/events", strings.NewReader(payload))
+	response = httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusForbidden, response.Code)
+	assert.Nil(t, received)
+}
+
+func TestVerifier_limits(t *testing.T) {
+	s := newSigner(t)
+	v, _ := NewVerifier(s.publicKey(t))
+	v.MaxBodySize = 64
+	called := false
+	handler := v.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	payload := `[{"email":"example@test.com","event":"delivered","sg_event_id":"a","sg_message_id":"b"}]`
+
+	assert.Equal(t, ErrBodyTooLarge, v.VerifyRequest(s.request(t, time.Now(), payload)))
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, s.request(t, time.Now(), payload))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, response.Code)
+
+	v.MaxBodySize = 0
+	assert.Nil(t, v.VerifyRequest(s.request(t, time.Now(), payload)), "DefaultMaxBodySize should be used")
+
+	var empty Verifier
+	assert.Equal(t, ErrNoPublicKey, empty.Verify([]byte(payload), "c2ln", "1600000000"), "A nil PublicKey should not panic")
+	response = httptest.NewRecorder()
+	empty.Middleware(handler).ServeHTTP(response, s.request(t, time.Now(), payload))
+	assert.Equal(t, http.StatusInternalServerError, response.Code)
+	assert.False(t, called)
+}
@@ -0,0 +1,110 @@
+package sendgrid
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/sendgrid/rest"
+)
+
+// config collects the settings applied by an Option
+type config struct {
+	host            string
+	subuser         string
+	userAgentSuffix string
+	httpClient      *http.Client
+	timeout         time.Duration
+	retryPolicy     *RetryPolicy
+	validateMail    bool
+}
+
+// Option configures a Client built with New
+type Option func(*config)
+
+// WithHTTPClient makes the client send its requests through httpClient
+// instead of a client of its own
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *config) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithHost overrides the API host, https://api.sendgrid.com by default
+func WithHost(host string) Option {
+	return func(c *config) {
+		c.host = host
+	}
+}
+
+// WithSubuser makes every request on behalf of the given subuser
+func WithSubuser(subuser string) Option {
+	return func(c *config) {
+		c.subuser = subuser
+	}
+}
+
+// WithUserAgentSuffix appends suffix to the User-Agent header
+func WithUserAgentSuffix(suffix string) Option {
+	return func(c *config) {
+		c.userAgentSuffix = suffix
+	}
+}
+
+// WithRetryPolicy makes the client retry failed requests according to
+// policy
+func WithRetryPolicy(policy *RetryPolicy) Option {
+	return func(c *config) {
+		c.retryPolicy = policy
+	}
+}
+
+// WithTimeout sets the timeout of each HTTP request. When combined with
+// WithHTTPClient, the given client is copied rather than modified.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.timeout = timeout
+	}
+}
+
+// WithMailValidation makes Send validate messages before sending them
+func WithMailValidation() Option {
+	return func(c *config) {
+		c.validateMail = true
+	}
+}
+
+// New constructs a Twilio SendGrid client given an API key. Unlike
+// NewSendClient, the client owns its HTTP transport and settings, and does
+// not use DefaultClient.
+func New(key string, opts ...Option) *Client {
+	var c config
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	httpClient := c.httpClient
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+	if c.timeout > 0 {
+		withTimeout := *httpClient
+		withTimeout.Timeout = c.timeout
+		httpClient = &withTimeout
+	}
+
+	o := options{
+		Key:             key,
+		Host:            c.host,
+		Subuser:         c.subuser,
+		UserAgentSuffix: c.userAgentSuffix,
+	}
+	cl := &Client{
+		RetryPolicy:  c.retryPolicy,
+		ValidateMail: c.validateMail,
+		options:      o,
+		restClient:   &rest.Client{HTTPClient: httpClient},
+	}
+	cl.Request = cl.GetRequest(mailSendEndpoint)
+	cl.Method = "POST"
+	return cl
+}
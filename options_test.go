@@ -0,0 +1,110 @@
+package sendgrid
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sendgrid/sendgrid-go/helpers/mail"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	client := New("API_KEY")
+	assert.Equal(t, "https://api.sendgrid.com/v3/mail/send", client.BaseURL, "Host default not set")
+	assert.Equal(t, "POST", string(client.Method))
+	assert.Equal(t, "Bearer API_KEY", client.Headers["Authorization"], "Wrong Authorization")
+	assert.Equal(t, "sendgrid/"+Version+";go", client.Headers["User-Agent"], "Wrong default User Agent")
+	assert.Nil(t, client.RetryPolicy)
+	assert.NotNil(t, client.restClient, "The client should own its transport")
+	assert.False(t, client.restClient == DefaultClient, "The client should not use DefaultClient")
+}
+
+func TestNew_options(t *testing.T) {
+	policy := DefaultRetryPolicy()
+	client := New("API_KEY",
+		WithHost("https://test.api.com"),
+		WithSubuser("subuserUsername"),
+		WithUserAgentSuffix("myapp/1.0"),
+		WithRetryPolicy(policy),
+	)
+	assert.Equal(t, "https://test.api.com/v3/mail/send", client.BaseURL)
+	assert.Equal(t, "sendgrid/"+Version+";go myapp/1.0", client.Headers["User-Agent"])
+	assert.Equal(t, policy, client.RetryPolicy)
+
+	request := client.GetRequest("/v3/endpoint")
+	assert.Equal(t, "https://test.api.com/v3/endpoint", request.BaseURL)
+	assert.Equal(t, "Bearer API_KEY", request.Headers["Authorization"])
+	assert.Equal(t, "subuserUsername", request.Headers["On-Behalf-Of"])
+	assert.Equal(t, "sendgrid/"+Version+";go myapp/1.0", request.Headers["User-Agent"])
+}
+
+func TestNew_withTimeout(t *testing.T) {
+	httpClient := &http.Client{}
+	client := New("API_KEY", WithHTTPClient(httpClient), WithTimeout(time.Second))
+	assert.Equal(t, time.Second, client.restClient.HTTPClient.Timeout)
+	assert.Equal(t, time.Duration(0), httpClient.Timeout, "The given HTTP client should not be modified")
+
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Millisecond * 20)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer fakeServer.Close()
+	client = New("API_KEY", WithHost(fakeServer.URL), WithTimeout(time.Millisecond*10))
+	request := client.GetRequest("/v3/test_endpoint")
+	request.Method = "GET"
+	_, err := client.MakeRequest(request)
+	assert.NotNil(t, err, "A timeout did not trigger as expected")
+	assert.True(t, strings.Contains(err.Error(), "Client.Timeout exceeded"), "We did not receive the Timeout error")
+}
+
+func TestNew_independentClients(t *testing.T) {
+	var keys []string
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer fakeServer.Close()
+	from := mail.NewEmail("Example User", "test@example.com")
+	to := mail.NewEmail("Example User", "test@example.com")
+	email := mail.NewSingleEmail(from, "subject", to, "text", "<p>html</p>")
+
+	first := New("FIRST_KEY", WithHost(fakeServer.URL))
+	second := New("SECOND_KEY", WithHost(fakeServer.URL), WithHTTPClient(fakeServer.Client()))
+	for _, client := range []*Client{first, second} {
+		response, err := client.Send(email)
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusAccepted, response.StatusCode)
+	}
+	assert.Equal(t, []string{"Bearer FIRST_KEY", "Bearer SECOND_KEY"}, keys)
+}
+
+func TestClientGetRequest_sendClient(t *testing.T) {
+	client := NewSendClientSubuser("API_KEY", "subuserUsername")
+	request := client.GetRequest("/v3/endpoint")
+	assert.Equal(t, "https://api.sendgrid.com/v3/endpoint", request.BaseURL)
+	ShouldHaveHeaders(&request, t)
+}
+
+func TestNew_withMailValidation(t *testing.T) {
+	var calls int
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer fakeServer.Close()
+	client := New("SENDGRID_APIKEY", WithHost(fakeServer.URL), WithMailValidation())
+	assert.True(t, client.ValidateMail)
+
+	_, err := client.Send(mail.NewV3Mail())
+	var verrs mail.ValidationErrors
+	assert.True(t, errors.As(err, &verrs), "Expected validation errors")
+	assert.Equal(t, 0, calls, "An invalid message should not be sent")
+
+	_, err = client.Send(testEmail())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, calls)
+}
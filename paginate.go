@@ -0,0 +1,339 @@
+package sendgrid
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sendgrid/rest"
+)
+
+// DefaultPageSize is the number of items requested per page when
+// PageOptions does not set one
+const DefaultPageSize = 100
+
+// PageOptions describes how a list endpoint is paginated
+type PageOptions struct {
+	// QueryParams are sent with every request, e.g. filters. An offset or
+	// page param sets the first page.
+	QueryParams map[string]string
+	// PageSize is the number of items per page, DefaultPageSize if zero. A
+	// page shortened to the maximum of the endpoint does not end the
+	// iteration.
+	PageSize int
+	// PageNumbers pages with page and page_size params instead of limit
+	// and offset
+	PageNumbers bool
+	// PageTokens pages with page_size and page_token params, following the
+	// next URL in the _metadata of each page, as /v3/templates does. A
+	// page_token param sets the first page.
+	PageTokens bool
+	// Field is the field holding the items for endpoints that return an
+	// object rather than an array, e.g. "recipients"
+	Field string
+}
+
+// Iterator iterates over the items of a list endpoint, requesting pages
+// as needed. Pages are followed through the next URL of the _metadata of
+// token paged endpoints, through the rel="next" Link header when the
+// response has one, and by offset or page number otherwise. The iteration
+// ends on an empty page or, for links and tokens, a page without a next
+// one; a page token seen twice stops it with an error.
+//
+//	it := sendgrid.Paginate[*sendgrid.Suppression](ctx, client, "/v3/suppression/bounces", nil)
+//	for it.Next() {
+//		fmt.Println(it.Value().Email)
+//	}
+//	if err := it.Err(); err != nil {
+//		log.Fatal(err)
+//	}
+//
+// Rate limited requests are retried once the limit resets, with the
+// RetryPolicy of the client if it has one, and a page is not requested
+// before the reset when the previous one used up the limit.
+type Iterator[T any] struct {
+	ctx         context.Context
+	client      *Client
+	endpoint    string
+	field       string
+	pageSize    int
+	pageNumbers bool
+	pageTokens  bool
+
+	// params are the query params of the next page, nil after the last
+	params  map[string]string
+	resetAt time.Time
+	// tokens are the page tokens requested so far
+	tokens map[string]bool
+	items  []T
+	value  T
+	err    error
+}
+
+// Paginate returns an iterator over the items of the list endpoint. The
+// items are decoded from the JSON of each page into values of type T.
+func Paginate[T any](ctx context.Context, cl *Client, endpoint string, opts *PageOptions) *Iterator[T] {
+	if opts == nil {
+		opts = &PageOptions{}
+	}
+	it := &Iterator[T]{
+		ctx:         ctx,
+		client:      cl,
+		endpoint:    endpoint,
+		field:       opts.Field,
+		pageSize:    opts.PageSize,
+		pageNumbers: opts.PageNumbers,
+		pageTokens:  opts.PageTokens,
+		params:      make(map[string]string),
+		tokens:      make(map[string]bool),
+	}
+	if it.pageSize <= 0 {
+		it.pageSize = DefaultPageSize
+	}
+	for key, value := range opts.QueryParams {
+		it.params[key] = value
+	}
+	size := strconv.Itoa(it.pageSize)
+	if it.pageTokens {
+		it.params["page_size"] = size
+		it.tokens[it.params["page_token"]] = true
+	} else if it.pageNumbers {
+		it.params["page_size"] = size
+		if it.params["page"] == "" {
+			it.params["page"] = "1"
+		}
+	} else {
+		it.params["limit"] = size
+		if it.params["offset"] == "" {
+			it.params["offset"] = "0"
+		}
+	}
+	return it
+}
+
+// Next advances to the next item, requesting the next page if needed. It
+// returns false after the last item, when ctx is done or a request
+// fails; Err tells them apart.
+func (it *Iterator[T]) Next() bool {
+	for len(it.items) == 0 {
+		if it.err != nil || it.params == nil {
+			return false
+		}
+		if err := it.ctx.Err(); err != nil {
+			it.err = err
+			return false
+		}
+		it.fetch()
+	}
+	it.value, it.items = it.items[0], it.items[1:]
+	return true
+}
+
+// Value returns the current item
+func (it *Iterator[T]) Value() T {
+	return it.value
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// fetch requests the page of it.params and sets the params of the next
+// one
+func (it *Iterator[T]) fetch() {
+	if err := sleepWithContext(it.ctx, time.Until(it.resetAt)); err != nil {
+		it.err = err
+		return
+	}
+	params := it.params
+	response, err := it.client.getPage(it.ctx, it.endpoint, params)
+	if err != nil {
+		it.err = err
+		return
+	}
+	items, err := decodePage[T](response.Body, it.field)
+	if err != nil {
+		it.err = fmt.Errorf("sendgrid: invalid page of %s: %v", it.endpoint, err)
+		return
+	}
+	it.items = items
+
+	it.resetAt = time.Time{}
+	if rl := ParseRateLimit(response); rl.Limit > 0 && rl.Remaining == 0 {
+		it.resetAt = rl.Reset
+	}
+
+	it.params = nil
+	if len(items) == 0 {
+		return
+	}
+	if it.pageTokens {
+		next, err := nextPageToken(response.Body)
+		if err != nil {
+			it.err = fmt.Errorf("sendgrid: invalid page of %s: %v", it.endpoint, err)
+			return
+		}
+		token := next["page_token"]
+		if token == "" {
+			return
+		}
+		if it.tokens[token] {
+			it.err = fmt.Errorf("sendgrid: %s returned the page token %q twice", it.endpoint, token)
+			return
+		}
+		it.tokens[token] = true
+		it.params = mergeParams(params, next)
+		return
+	}
+	if next := nextLink(response); next != nil {
+		if next = mergeParams(params, next); !sameParams(next, params) {
+			it.params = next
+		}
+		return
+	}
+	// a page shorter than the page size is not the last one when the
+	// endpoint caps pages at a lower size, only an empty page is
+	it.params = make(map[string]string)
+	for key, value := range params {
+		it.params[key] = value
+	}
+	if it.pageNumbers {
+		page, _ := strconv.Atoi(params["page"])
+		it.params["page"] = strconv.Itoa(page + 1)
+	} else {
+		offset, _ := strconv.Atoi(params["offset"])
+		it.params["offset"] = strconv.Itoa(offset + len(items))
+	}
+}
+
+// getPage requests a page of a list endpoint. Rate limited requests are
+// retried when the client has no RetryPolicy.
+func (cl *Client) getPage(ctx context.Context, endpoint string, params map[string]string) (*rest.Response, error) {
+	request := cl.GetRequest(endpoint)
+	request.Method = rest.Get
+	request.QueryParams = params
+
+	var response *rest.Response
+	var err error
+	if cl.RetryPolicy == nil {
+		response, err = rateLimitRetryPolicy().do(ctx, cl.send, request)
+	} else {
+		response, err = cl.MakeRequestWithContext(ctx, request)
+	}
+	if err == nil {
+		err = CheckResponse(response)
+	}
+	return response, withRequiredScopes(err, request)
+}
+
+// decodePage decodes the items of a page, from field if it is set
+func decodePage[T any](body, field string) ([]T, error) {
+	if strings.TrimSpace(body) == "" {
+		return nil, nil
+	}
+	raw := json.RawMessage(body)
+	if field != "" {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			return nil, err
+		}
+		if raw = fields[field]; raw == nil {
+			return nil, nil
+		}
+	}
+	var items []T
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// nextPageToken returns the query params of the next URL in the _metadata
+// of a token paged response, or nil if it has none
+func nextPageToken(body string) (map[string]string, error) {
+	var page struct {
+		Metadata struct {
+			Next string `json:"next"`
+		} `json:"_metadata"`
+	}
+	if err := json.Unmarshal([]byte(body), &page); err != nil {
+		return nil, err
+	}
+	if page.Metadata.Next == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(page.Metadata.Next)
+	if err != nil {
+		return nil, err
+	}
+	return firstValues(u.Query()), nil
+}
+
+// nextLink returns the query params of the rel="next" Link header of
+// response, or nil if it has none
+func nextLink(response *rest.Response) map[string]string {
+	for _, header := range http.Header(response.Headers).Values("Link") {
+		for _, link := range strings.Split(header, ",") {
+			parts := strings.Split(link, ";")
+			target := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			next := false
+			for _, param := range parts[1:] {
+				if strings.Replace(strings.TrimSpace(param), " ", "", -1) == `rel="next"` {
+					next = true
+				}
+			}
+			if !next {
+				continue
+			}
+			u, err := url.Parse(target[1 : len(target)-1])
+			if err != nil {
+				return nil
+			}
+			return firstValues(u.Query())
+		}
+	}
+	return nil
+}
+
+// mergeParams returns the params of the next page, which override those of
+// the current one. Next page URLs may leave out filters such as start_time.
+func mergeParams(params, next map[string]string) map[string]string {
+	merged := make(map[string]string, len(params)+len(next))
+	for key, value := range params {
+		merged[key] = value
+	}
+	for key, value := range next {
+		merged[key] = value
+	}
+	return merged
+}
+
+// firstValues returns the first value of each query param
+func firstValues(query url.Values) map[string]string {
+	params := make(map[string]string, len(query))
+	for key, values := range query {
+		params[key] = values[0]
+	}
+	return params
+}
+
+func sameParams(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range a {
+		if b[key] != value {
+			return false
+		}
+	}
+	return true
+}
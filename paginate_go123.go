@@ -0,0 +1,28 @@
+//go:build go1.23
+
+package sendgrid
+
+import "iter"
+
+// All returns the items of the iterator as a sequence for range loops.
+// The sequence ends with the error that stopped the iteration, if any.
+//
+//	for bounce, err := range client.Suppressions().Bounces().Iterate(ctx, nil).All() {
+//		if err != nil {
+//			log.Fatal(err)
+//		}
+//		fmt.Println(bounce.Email)
+//	}
+func (it *Iterator[T]) All() iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for it.Next() {
+			if !yield(it.Value(), nil) {
+				return
+			}
+		}
+		if err := it.Err(); err != nil {
+			var zero T
+			yield(zero, err)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+//go:build go1.23
+
+package sendgrid
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIterator_All(t *testing.T) {
+	fakeServer := newFakeList(250)
+	defer fakeServer.Close()
+	client := New("SENDGRID_APIKEY", WithHost(fakeServer.URL))
+
+	count := 0
+	for bounce, err := range client.Suppressions().Bounces().Iterate(context.Background(), nil).All() {
+		if !assert.Nil(t, err) {
+			break
+		}
+		assert.NotEqual(t, "", bounce.Email)
+		count++
+	}
+	assert.Equal(t, 250, count)
+
+	fakeServer.requests = nil
+	for range Paginate[*Suppression](context.Background(), client, "/v3/suppression/bounces", nil).All() {
+		break
+	}
+	assert.Equal(t, 1, len(fakeServer.requests), "Breaking out of the loop should stop requesting pages")
+
+	fakeServer.handle = func(w http.ResponseWriter, r *http.Request) bool {
+		w.WriteHeader(http.StatusUnauthorized)
+		return true
+	}
+	var errs []error
+	for bounce, err := range Paginate[*Suppression](context.Background(), client, "/v3/suppression/bounces", nil).All() {
+		assert.Nil(t, bounce)
+		errs = append(errs, err)
+	}
+	if assert.Equal(t, 1, len(errs)) {
+		assert.True(t, IsUnauthorized(errs[0]))
+	}
+}
@@ -0,0 +1,386 @@
+package sendgrid
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeList serves total numbered items with limit and offset or page and
+// page_size params
+type fakeList struct {
+	*httptest.Server
+	mu       sync.Mutex
+	total    int
+	maxLimit int
+	requests []string
+	// handle, if set, can answer a request instead of the list
+	handle func(w http.ResponseWriter, r *http.Request) bool
+}
+
+func newFakeList(total int) *fakeList {
+	s := &fakeList{total: total}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+func (s *fakeList) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r.URL.RequestURI())
+	handle := s.handle
+	s.mu.Unlock()
+	if handle != nil && handle(w, r) {
+		return
+	}
+
+	query := r.URL.Query()
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	offset, _ := strconv.Atoi(query.Get("offset"))
+	if size := query.Get("page_size"); size != "" {
+		limit, _ = strconv.Atoi(size)
+		page, _ := strconv.Atoi(query.Get("page"))
+		offset = (page - 1) * limit
+	}
+	if s.maxLimit > 0 && limit > s.maxLimit {
+		limit = s.maxLimit
+	}
+	items := []map[string]interface{}{}
+	for i := offset; i < offset+limit && i < s.total; i++ {
+		items = append(items, map[string]interface{}{"email": fmt.Sprintf("%d@example.com", i), "created": 1600000000 + i})
+	}
+	if r.URL.Path == "/v3/contactdb/recipients" {
+		writeJSON(w, map[string]interface{}{"recipients": items})
+		return
+	}
+	writeJSON(w, items)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	b, _ := json.Marshal(v)
+	w.Write(b)
+}
+
+func TestPaginate_offset(t *testing.T) {
+	fakeServer := newFakeList(250)
+	defer fakeServer.Close()
+	client := New("SENDGRID_APIKEY", WithHost(fakeServer.URL))
+
+	it := Paginate[*Suppression](context.Background(), client, "/v3/suppression/bounces", &PageOptions{
+		QueryParams: map[string]string{"start_time": "1"},
+	})
+	var emails []string
+	for it.Next() {
+		emails = append(emails, it.Value().Email)
+	}
+	assert.Nil(t, it.Err())
+	if assert.Equal(t, 250, len(emails)) {
+		assert.Equal(t, "0@example.com", emails[0])
+		assert.Equal(t, "249@example.com", emails[249])
+	}
+	assert.Equal(t, []string{
+		"/v3/suppression/bounces?limit=100&offset=0&start_time=1",
+		"/v3/suppression/bounces?limit=100&offset=100&start_time=1",
+		"/v3/suppression/bounces?limit=100&offset=200&start_time=1",
+		"/v3/suppression/bounces?limit=100&offset=250&start_time=1",
+	}, fakeServer.requests, "An empty page should end the iteration")
+	assert.False(t, it.Next(), "Next should keep returning false")
+
+	// the server caps pages at 50 items, a short page is not the last one
+	fakeServer.requests = nil
+	fakeServer.maxLimit = 50
+	it = Paginate[*Suppression](context.Background(), client, "/v3/suppression/bounces", nil)
+	count := 0
+	for it.Next() {
+		count++
+	}
+	assert.Nil(t, it.Err())
+	assert.Equal(t, 250, count)
+	assert.Equal(t, 6, len(fakeServer.requests))
+	fakeServer.maxLimit = 0
+
+	fakeServer.requests = nil
+	fakeServer.total = 200
+	it = Paginate[*Suppression](context.Background(), client, "/v3/suppression/bounces", nil)
+	count = 0
+	for it.Next() {
+		count++
+	}
+	assert.Nil(t, it.Err())
+	assert.Equal(t, 200, count)
+	assert.Equal(t, 3, len(fakeServer.requests), "An empty page should end the iteration")
+}
+
+func TestPaginate_pageNumbers(t *testing.T) {
+	fakeServer := newFakeList(25)
+	defer fakeServer.Close()
+	client := New("SENDGRID_APIKEY", WithHost(fakeServer.URL))
+
+	it := Paginate[map[string]interface{}](context.Background(), client, "/v3/contactdb/recipients", &PageOptions{
+		PageSize:    10,
+		PageNumbers: true,
+		Field:       "recipients",
+	})
+	count := 0
+	for it.Next() {
+		assert.Equal(t, fmt.Sprintf("%d@example.com", count), it.Value()["email"])
+		count++
+	}
+	assert.Nil(t, it.Err())
+	assert.Equal(t, 25, count)
+	assert.Equal(t, []string{
+		"/v3/contactdb/recipients?page=1&page_size=10",
+		"/v3/contactdb/recipients?page=2&page_size=10",
+		"/v3/contactdb/recipients?page=3&page_size=10",
+		"/v3/contactdb/recipients?page=4&page_size=10",
+	}, fakeServer.requests)
+}
+
+func TestPaginate_linkHeader(t *testing.T) {
+	fakeServer := newFakeList(120)
+	defer fakeServer.Close()
+	fakeServer.maxLimit = 50
+	fakeServer.handle = func(w http.ResponseWriter, r *http.Request) bool {
+		// like SendGrid, the last page links to itself as next
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		next := offset + 50
+		if next >= 120 {
+			next = offset
+		}
+		w.Header().Add("Link", fmt.Sprintf(`<%s/v3/whitelabel/domains?limit=50&offset=0>; rel="first"; title="1", <%s/v3/whitelabel/domains?limit=50&offset=%d>; rel="next"; title="2"`, fakeServer.URL, fakeServer.URL, next))
+		return false
+	}
+	client := New("SENDGRID_APIKEY", WithHost(fakeServer.URL))
+
+	// the server caps pages at 50 items, the links keep the iteration going
+	it := Paginate[*Suppression](context.Background(), client, "/v3/whitelabel/domains", &PageOptions{PageSize: 100})
+	count := 0
+	for it.Next() {
+		count++
+	}
+	assert.Nil(t, it.Err())
+	assert.Equal(t, 120, count)
+	assert.Equal(t, []string{
+		"/v3/whitelabel/domains?limit=100&offset=0",
+		"/v3/whitelabel/domains?limit=50&offset=50",
+		"/v3/whitelabel/domains?limit=50&offset=100",
+	}, fakeServer.requests)
+}
+
+func TestPaginate_linkHeaderFilters(t *testing.T) {
+	fakeServer := newFakeList(150)
+	defer fakeServer.Close()
+	fakeServer.handle = func(w http.ResponseWriter, r *http.Request) bool {
+		// the next link leaves out start_time
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		w.Header().Add("Link", fmt.Sprintf(`<%s/v3/suppression/bounces?limit=100&offset=%d>; rel="next"`, fakeServer.URL, offset+100))
+		return false
+	}
+	client := New("SENDGRID_APIKEY", WithHost(fakeServer.URL))
+
+	it := client.Suppressions().Bounces().Iterate(context.Background(), &SuppressionListOptions{Start: time.Unix(1600000000, 0), Limit: 100})
+	count := 0
+	for it.Next() {
+		count++
+	}
+	assert.Nil(t, it.Err())
+	assert.Equal(t, 150, count)
+	assert.Equal(t, []string{
+		"/v3/suppression/bounces?limit=100&offset=0&start_time=1600000000",
+		"/v3/suppression/bounces?limit=100&offset=100&start_time=1600000000",
+		"/v3/suppression/bounces?limit=100&offset=200&start_time=1600000000",
+	}, fakeServer.requests, "The filters should be kept when the next link leaves them out")
+}
+
+func TestPaginate_pageTokens(t *testing.T) {
+	fakeServer := newFakeList(0)
+	defer fakeServer.Close()
+	tokens := map[string]string{"": "b", "b": "c", "c": ""}
+	fakeServer.handle = func(w http.ResponseWriter, r *http.Request) bool {
+		// like /v3/templates, the next URL has the page_size and page_token only
+		token := r.URL.Query().Get("page_token")
+		page := map[string]interface{}{
+			"result":    []map[string]string{{"id": token + "1"}, {"id": token + "2"}},
+			"_metadata": map[string]interface{}{"count": 6},
+		}
+		if next := tokens[token]; next != "" {
+			page["_metadata"] = map[string]interface{}{
+				"next":  fmt.Sprintf("%s/v3/templates?page_size=2&page_token=%s", fakeServer.URL, next),
+				"count": 6,
+			}
+		}
+		writeJSON(w, page)
+		return true
+	}
+	client := New("SENDGRID_APIKEY", WithHost(fakeServer.URL))
+
+	it := Paginate[map[string]string](context.Background(), client, "/v3/templates", &PageOptions{
+		QueryParams: map[string]string{"generations": "dynamic"},
+		PageSize:    2,
+		PageTokens:  true,
+		Field:       "result",
+	})
+	var ids []string
+	for it.Next() {
+		ids = append(ids, it.Value()["id"])
+	}
+	assert.Nil(t, it.Err())
+	assert.Equal(t, []string{"1", "2", "b1", "b2", "c1", "c2"}, ids)
+	assert.Equal(t, []string{
+		"/v3/templates?generations=dynamic&page_size=2",
+		"/v3/templates?generations=dynamic&page_size=2&page_token=b",
+		"/v3/templates?generations=dynamic&page_size=2&page_token=c",
+	}, fakeServer.requests, "The filters should be kept when the next URL leaves them out")
+
+	// a repeated token would loop forever
+	fakeServer.requests = nil
+	tokens["c"] = "b"
+	it = Paginate[map[string]string](context.Background(), client, "/v3/templates", &PageOptions{
+		PageSize:   2,
+		PageTokens: true,
+		Field:      "result",
+	})
+	count := 0
+	for it.Next() {
+		count++
+	}
+	assert.Equal(t, 6, count, "The items of the page should be returned before the error")
+	if assert.NotNil(t, it.Err()) {
+		assert.Contains(t, it.Err().Error(), `page token "b" twice`)
+	}
+	assert.Equal(t, 3, len(fakeServer.requests))
+}
+
+func TestPaginate_rateLimit(t *testing.T) {
+	fakeServer := newFakeList(150)
+	defer fakeServer.Close()
+	limited := true
+	fakeServer.handle = func(w http.ResponseWriter, r *http.Request) bool {
+		if limited {
+			limited = false
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Unix()-1, 10))
+			w.WriteHeader(http.StatusTooManyRequests)
+			return true
+		}
+		return false
+	}
+	client := New("SENDGRID_APIKEY", WithHost(fakeServer.URL))
+
+	it := Paginate[*Suppression](context.Background(), client, "/v3/suppression/blocks", nil)
+	count := 0
+	for it.Next() {
+		count++
+	}
+	assert.Nil(t, it.Err(), "Rate limited requests should be retried")
+	assert.Equal(t, 150, count)
+	assert.Equal(t, 4, len(fakeServer.requests))
+
+	// a used up limit delays the next page until the reset
+	fakeServer.requests = nil
+	fakeServer.handle = func(w http.ResponseWriter, r *http.Request) bool {
+		w.Header().Set("X-RateLimit-Limit", "600")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	it = Paginate[*Suppression](ctx, client, "/v3/suppression/blocks", nil)
+	count = 0
+	for it.Next() {
+		count++
+	}
+	assert.Equal(t, context.DeadlineExceeded, it.Err())
+	assert.Equal(t, 100, count)
+	assert.Equal(t, 1, len(fakeServer.requests))
+}
+
+func TestPaginate_errors(t *testing.T) {
+	fakeServer := newFakeList(150)
+	defer fakeServer.Close()
+	client := New("SENDGRID_APIKEY", WithHost(fakeServer.URL))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	it := Paginate[*Suppression](ctx, client, "/v3/suppression/blocks", nil)
+	assert.False(t, it.Next())
+	assert.Equal(t, context.Canceled, it.Err())
+	assert.Equal(t, 0, len(fakeServer.requests))
+
+	fakeServer.handle = func(w http.ResponseWriter, r *http.Request) bool {
+		if r.URL.Query().Get("offset") == "100" {
+			w.WriteHeader(http.StatusForbidden)
+			return true
+		}
+		return false
+	}
+	it = Paginate[*Suppression](context.Background(), client, "/v3/suppression/blocks", nil)
+	count := 0
+	for it.Next() {
+		count++
+	}
+	assert.Equal(t, 100, count)
+	assert.True(t, IsForbidden(it.Err()))
+
+	fakeServer.handle = func(w http.ResponseWriter, r *http.Request) bool {
+		w.Write([]byte(`{"not":"a list"}`))
+		return true
+	}
+	it = Paginate[*Suppression](context.Background(), client, "/v3/suppression/blocks", nil)
+	assert.False(t, it.Next())
+	assert.NotNil(t, it.Err())
+
+	it = Paginate[*Suppression](context.Background(), client, "/v3/suppression/blocks", &PageOptions{Field: "result"})
+	assert.False(t, it.Next())
+	assert.Nil(t, it.Err(), "A missing field is an empty page")
+
+	client.RetryPolicy = &RetryPolicy{MaxAttempts: 1}
+	fakeServer.handle = func(w http.ResponseWriter, r *http.Request) bool {
+		w.WriteHeader(http.StatusTooManyRequests)
+		return true
+	}
+	it = Paginate[*Suppression](context.Background(), client, "/v3/suppression/blocks", nil)
+	assert.False(t, it.Next())
+	assert.True(t, IsRateLimited(it.Err()), "The retry policy of the client should be used")
+}
+
+func TestSuppressionList_Iterate(t *testing.T) {
+	fakeServer := newFakeList(1200)
+	defer fakeServer.Close()
+	bounces := New("SENDGRID_APIKEY", WithHost(fakeServer.URL)).Suppressions().Bounces()
+
+	it := bounces.Iterate(context.Background(), &SuppressionListOptions{Start: time.Unix(1600000000, 0)})
+	count := 0
+	for it.Next() {
+		assert.Equal(t, time.Unix(int64(1600000000+count), 0), it.Value().Created)
+		count++
+	}
+	assert.Nil(t, it.Err())
+	assert.Equal(t, 1200, count)
+	assert.Equal(t, []string{
+		"/v3/suppression/bounces?limit=500&offset=0&start_time=1600000000",
+		"/v3/suppression/bounces?limit=500&offset=500&start_time=1600000000",
+		"/v3/suppression/bounces?limit=500&offset=1000&start_time=1600000000",
+		"/v3/suppression/bounces?limit=500&offset=1200&start_time=1600000000",
+	}, fakeServer.requests)
+
+	fakeServer.requests = nil
+	it = bounces.Iterate(context.Background(), &SuppressionListOptions{Limit: 100, Offset: 1150})
+	count = 0
+	for it.Next() {
+		count++
+	}
+	assert.Equal(t, 50, count)
+	assert.Equal(t, []string{
+		"/v3/suppression/bounces?limit=100&offset=1150",
+		"/v3/suppression/bounces?limit=100&offset=1200",
+	}, fakeServer.requests)
+}
@@ -0,0 +1,190 @@
+package sendgrid
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/sendgrid/rest"
+)
+
+// ErrRateLimitRetryExceeded is wrapped by the *APIError returned when a
+// request is still rate limited after all the attempts allowed by the retry
+// policy, along with the last response and its rate limit headers
+var ErrRateLimitRetryExceeded = errors.New("Rate limit retry exceeded")
+
+// RetryPolicy controls how failed requests are retried.
+//
+// Retrying a POST to /v3/mail/send after a network error may deliver the
+// message twice if the first request reached SendGrid; set RetryableError to
+// nil to only retry on status codes.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one
+	MaxAttempts int
+	// BaseBackoff is the wait before the first retry; it doubles on every
+	// following retry
+	BaseBackoff time.Duration
+	// MaxBackoff caps the computed backoff, it does not cap waits requested
+	// by an X-RateLimit-Reset header
+	MaxBackoff time.Duration
+	// Jitter is the fraction, between 0 and 1, of the backoff that is
+	// randomly removed from each wait
+	Jitter float64
+	// RetryableStatusCodes lists the response status codes to retry
+	RetryableStatusCodes []int
+	// RetryableError reports whether a transport error should be retried
+	RetryableError func(error) bool
+	// MaxElapsed is the total time budget across all attempts and waits,
+	// zero means no limit
+	MaxElapsed time.Duration
+}
+
+// DefaultRetryPolicy returns a policy retrying rate limits, transient 5xx
+// responses and temporary network errors with exponential backoff
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts: 5,
+		BaseBackoff: 500 * time.Millisecond,
+		MaxBackoff:  30 * time.Second,
+		Jitter:      0.2,
+		RetryableStatusCodes: []int{
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+		RetryableError: IsTemporaryError,
+		MaxElapsed:     2 * time.Minute,
+	}
+}
+
+// rateLimitRetryPolicy is the policy used by MakeRequestRetry: only rate
+// limited responses are retried, at a fixed interval
+func rateLimitRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:          rateLimitRetry + 2,
+		BaseBackoff:          rateLimitSleep * time.Millisecond,
+		MaxBackoff:           rateLimitSleep * time.Millisecond,
+		RetryableStatusCodes: []int{http.StatusTooManyRequests},
+	}
+}
+
+// IsTemporaryError reports whether err is a network error that is likely to
+// succeed on retry, such as a timeout or a reset connection. Context
+// cancellation is never temporary.
+func IsTemporaryError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// retryableStatus reports whether the policy retries the status code
+func (p *RetryPolicy) retryableStatus(statusCode int) bool {
+	for _, code := range p.RetryableStatusCodes {
+		if code == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the wait before the given retry, counting from zero
+func (p *RetryPolicy) backoff(retry int) time.Duration {
+	d := p.BaseBackoff
+	for i := 0; i < retry && (p.MaxBackoff <= 0 || d < p.MaxBackoff); i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if p.Jitter > 0 {
+		d -= time.Duration(rand.Float64() * p.Jitter * float64(d))
+	}
+	return d
+}
+
+// wait returns how long to wait before the given retry, honoring the
+// X-RateLimit-Reset header when the response carries one
+func (p *RetryPolicy) wait(retry int, response *rest.Response) time.Duration {
+	if response != nil {
+		reset, err := strconv.ParseInt(headerValue(response.Headers, "X-RateLimit-Reset"), 10, 64)
+		if err == nil {
+			return time.Unix(reset, 0).Sub(time.Now())
+		}
+	}
+	return p.backoff(retry)
+}
+
+// do sends the request with send, retrying according to the policy
+func (p *RetryPolicy) do(ctx context.Context, send func(context.Context, rest.Request) (*rest.Response, error), request rest.Request) (*rest.Response, error) {
+	var deadline time.Time
+	if p.MaxElapsed > 0 {
+		deadline = time.Now().Add(p.MaxElapsed)
+	}
+
+	for retry := 0; ; retry++ {
+		response, err := send(ctx, request)
+		if err != nil {
+			// a response received before ctx is done is kept, it may be a
+			// message that was accepted
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return nil, ctxErr
+			}
+		}
+
+		var retryable bool
+		if err != nil {
+			retryable = p.RetryableError != nil && p.RetryableError(err)
+		} else {
+			retryable = p.retryableStatus(response.StatusCode)
+		}
+
+		wait := p.wait(retry, response)
+		if retryable && retry+1 < p.MaxAttempts && (deadline.IsZero() || !time.Now().Add(wait).After(deadline)) {
+			if err := sleepWithContext(ctx, wait); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+		if retryable && response.StatusCode == http.StatusTooManyRequests {
+			apiErr := CheckResponse(response).(*APIError)
+			apiErr.err = ErrRateLimitRetryExceeded
+			return response, apiErr
+		}
+		return response, nil
+	}
+}
+
+// sleepWithContext pauses for d, returning early with ctx.Err() if ctx is
+// done first.
+func sleepWithContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
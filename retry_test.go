@@ -0,0 +1,229 @@
+package sendgrid
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/sendgrid/rest"
+	"github.com/sendgrid/sendgrid-go/helpers/mail"
+	"github.com/stretchr/testify/assert"
+)
+
+func testRetryPolicy() *RetryPolicy {
+	policy := DefaultRetryPolicy()
+	policy.BaseBackoff = time.Millisecond
+	policy.MaxBackoff = 5 * time.Millisecond
+	return policy
+}
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	policy := &RetryPolicy{BaseBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	assert.Equal(t, 100*time.Millisecond, policy.backoff(0))
+	assert.Equal(t, 200*time.Millisecond, policy.backoff(1))
+	assert.Equal(t, 800*time.Millisecond, policy.backoff(3))
+	assert.Equal(t, time.Second, policy.backoff(4), "Backoff should be capped")
+	assert.Equal(t, time.Second, policy.backoff(100), "Backoff should not overflow")
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		d := policy.backoff(0)
+		assert.True(t, d > 50*time.Millisecond && d <= 100*time.Millisecond, fmt.Sprintf("Jittered backoff out of range: %v", d))
+	}
+}
+
+func TestRetryPolicy_rateLimitReset(t *testing.T) {
+	policy := testRetryPolicy()
+	reset := time.Now().Add(10 * time.Second)
+	response := &rest.Response{Headers: map[string][]string{"X-RateLimit-Reset": {strconv.Itoa(int(reset.Unix()))}}}
+	wait := policy.wait(0, response)
+	assert.True(t, wait > 8*time.Second, "X-RateLimit-Reset should take precedence over backoff")
+	assert.True(t, policy.wait(0, &rest.Response{}) <= policy.MaxBackoff, "Backoff should be used without X-RateLimit-Reset")
+
+	// net/http stores the header under its canonical key
+	header := http.Header{}
+	header.Set("X-RateLimit-Reset", strconv.Itoa(int(reset.Unix())))
+	assert.True(t, policy.wait(0, &rest.Response{Headers: header}) > 8*time.Second, "X-Ratelimit-Reset should take precedence over backoff")
+}
+
+func TestIsTemporaryError(t *testing.T) {
+	assert.True(t, IsTemporaryError(fmt.Errorf("read: %w", syscall.ECONNRESET)))
+	assert.True(t, IsTemporaryError(io.ErrUnexpectedEOF))
+	assert.False(t, IsTemporaryError(context.Canceled))
+	assert.False(t, IsTemporaryError(errors.New("unsupported protocol scheme")))
+	assert.False(t, IsTemporaryError(nil))
+}
+
+func TestMakeRequestRetryWithPolicy_serverError(t *testing.T) {
+	var calls int32
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer fakeServer.Close()
+	request := GetRequest("SENDGRID_APIKEY", "/v3/test_endpoint", fakeServer.URL)
+	request.Method = "GET"
+	response, err := MakeRequestRetryWithPolicy(context.Background(), request, testRetryPolicy())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestMakeRequestRetryWithPolicy_exhausted(t *testing.T) {
+	var calls int32
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer fakeServer.Close()
+	request := GetRequest("SENDGRID_APIKEY", "/v3/test_endpoint", fakeServer.URL)
+	request.Method = "GET"
+	policy := testRetryPolicy()
+	policy.MaxAttempts = 3
+	response, err := MakeRequestRetryWithPolicy(context.Background(), request, policy)
+	assert.Nil(t, err, "An exhausted 5xx should return the last response")
+	assert.Equal(t, http.StatusBadGateway, response.StatusCode)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestMakeRequestRetryWithPolicy_notRetryable(t *testing.T) {
+	var calls int32
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer fakeServer.Close()
+	request := GetRequest("SENDGRID_APIKEY", "/v3/test_endpoint", fakeServer.URL)
+	request.Method = "GET"
+	response, err := MakeRequestRetryWithPolicy(context.Background(), request, testRetryPolicy())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestMakeRequestRetryWithPolicy_networkError(t *testing.T) {
+	var calls int32
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer fakeServer.Close()
+	request := GetRequest("SENDGRID_APIKEY", "/v3/test_endpoint", fakeServer.URL)
+	request.Method = "GET"
+	response, err := MakeRequestRetryWithPolicy(context.Background(), request, testRetryPolicy())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestMakeRequestRetryWithPolicy_timeBudget(t *testing.T) {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Reset", strconv.Itoa(int(time.Now().Add(60*time.Second).Unix())))
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer fakeServer.Close()
+	request := GetRequest("SENDGRID_APIKEY", "/v3/test_endpoint", fakeServer.URL)
+	request.Method = "GET"
+	policy := testRetryPolicy()
+	policy.MaxElapsed = time.Second
+	start := time.Now()
+	response, err := MakeRequestRetryWithPolicy(context.Background(), request, policy)
+	assert.True(t, errors.Is(err, ErrRateLimitRetryExceeded))
+	assert.True(t, IsRateLimited(err), "The error should be the *APIError of the last response")
+	assert.True(t, time.Since(start) < time.Second, "A wait beyond the time budget should not be attempted")
+	if assert.NotNil(t, response, "The last response should be returned with the error") {
+		assert.Equal(t, http.StatusTooManyRequests, response.StatusCode)
+		assert.False(t, ParseRateLimit(response).Reset.IsZero())
+	}
+}
+
+func TestRetryPolicy_contextDoneAfterResponse(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	accepted := func(context.Context, rest.Request) (*rest.Response, error) {
+		// the deadline passes right after the message was accepted
+		cancel()
+		return &rest.Response{StatusCode: http.StatusAccepted}, nil
+	}
+	response, err := testRetryPolicy().do(ctx, accepted, rest.Request{})
+	assert.Nil(t, err, "A received response should not be replaced by the context error")
+	assert.Equal(t, http.StatusAccepted, response.StatusCode)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	limited := func(context.Context, rest.Request) (*rest.Response, error) {
+		cancel()
+		return &rest.Response{StatusCode: http.StatusTooManyRequests}, nil
+	}
+	_, err = testRetryPolicy().do(ctx, limited, rest.Request{})
+	assert.Equal(t, context.Canceled, err, "A retry should not be attempted once ctx is done")
+
+	ctx, cancel = context.WithCancel(context.Background())
+	failed := func(context.Context, rest.Request) (*rest.Response, error) {
+		cancel()
+		return nil, errors.New("connection reset")
+	}
+	_, err = testRetryPolicy().do(ctx, failed, rest.Request{})
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestSend_retryPolicy(t *testing.T) {
+	var calls int32
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer fakeServer.Close()
+	client := NewSendClient("SENDGRID_APIKEY")
+	client.BaseURL = fakeServer.URL + "/v3/mail/send"
+	client.RetryPolicy = testRetryPolicy()
+	from := mail.NewEmail("Example User", "test@example.com")
+	to := mail.NewEmail("Example User", "test@example.com")
+	response, err := client.Send(mail.NewSingleEmail(from, "subject", to, "text", "<p>html</p>"))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusAccepted, response.StatusCode)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestSend_retryPolicyRateLimited(t *testing.T) {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "abc123")
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(w, `{"errors":[{"message":"too many requests"}]}`)
+	}))
+	defer fakeServer.Close()
+	client := NewSendClient("SENDGRID_APIKEY")
+	client.BaseURL = fakeServer.URL + "/v3/mail/send"
+	client.RetryPolicy = testRetryPolicy()
+	client.RetryPolicy.MaxAttempts = 2
+	client.ReturnAPIErrors = true
+	from := mail.NewEmail("Example User", "test@example.com")
+	to := mail.NewEmail("Example User", "test@example.com")
+	response, err := client.Send(mail.NewSingleEmail(from, "subject", to, "text", "<p>html</p>"))
+	var apiErr *APIError
+	if assert.True(t, errors.As(err, &apiErr), "An exhausted 429 should be an *APIError") {
+		assert.Equal(t, "abc123", apiErr.RequestID)
+		assert.Equal(t, "too many requests", apiErr.Errors[0].Message)
+	}
+	assert.True(t, IsRateLimited(err))
+	assert.True(t, errors.Is(err, ErrRateLimitRetryExceeded))
+	assert.Equal(t, http.StatusTooManyRequests, response.StatusCode)
+}
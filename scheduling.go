@@ -0,0 +1,160 @@
+package sendgrid
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/sendgrid/rest"
+	"github.com/sendgrid/sendgrid-go/helpers/mail"
+)
+
+// MaxScheduleAhead is how far in the future a message can be scheduled
+const MaxScheduleAhead = 72 * time.Hour
+
+// ErrScheduleWindow is returned when a send time is outside the scheduling
+// window
+var ErrScheduleWindow = errors.New("sendgrid: send_at must be in the next 72 hours")
+
+// ScheduleStatus is the status of a batch of scheduled sends
+type ScheduleStatus string
+
+// Statuses of a batch of scheduled sends
+const (
+	// ScheduleStatusPause holds the messages until the batch is resumed
+	ScheduleStatusPause ScheduleStatus = "pause"
+	// ScheduleStatusCancel discards the messages when they are due
+	ScheduleStatusCancel ScheduleStatus = "cancel"
+)
+
+// ScheduledSend is a batch of scheduled sends that was paused or cancelled
+type ScheduledSend struct {
+	BatchID string         `json:"batch_id"`
+	Status  ScheduleStatus `json:"status"`
+}
+
+// ValidateSendAt checks that at is in the future and within
+// MaxScheduleAhead
+func ValidateSendAt(at time.Time) error {
+	now := time.Now()
+	if !at.After(now) || at.Sub(now) > MaxScheduleAhead {
+		return ErrScheduleWindow
+	}
+	return nil
+}
+
+// CreateBatchID creates a batch ID for grouping scheduled sends
+func (cl *Client) CreateBatchID(ctx context.Context) (string, error) {
+	var batch ScheduledSend
+	if _, err := cl.doJSON(ctx, rest.Post, "/v3/mail/batch", nil, nil, &batch); err != nil {
+		return "", err
+	}
+	if batch.BatchID == "" {
+		return "", errors.New("sendgrid: no batch_id in the response")
+	}
+	return batch.BatchID, nil
+}
+
+// ValidateBatchID checks that batchID is a valid batch ID. Unknown IDs
+// are reported as an *APIError.
+func (cl *Client) ValidateBatchID(ctx context.Context, batchID string) error {
+	_, err := cl.doJSON(ctx, rest.Get, "/v3/mail/batch/"+url.PathEscape(batchID), nil, nil, nil)
+	return err
+}
+
+// ScheduleSend sends email at the given time as part of a batch that can
+// later be paused, cancelled or resumed. A batch ID is created unless the
+// message has one; either way it is returned in the result's BatchID. The
+// batch ID and send time are set on a copy, leaving email unchanged.
+//
+// Non-2xx responses are returned as *APIError whatever ReturnAPIErrors is
+// set to, along with the result.
+func (cl *Client) ScheduleSend(ctx context.Context, email *mail.SGMailV3, at time.Time) (*SendResult, error) {
+	if err := ValidateSendAt(at); err != nil {
+		return nil, err
+	}
+	scheduled := *email
+	if scheduled.BatchID == "" {
+		batchID, err := cl.CreateBatchID(ctx)
+		if err != nil {
+			return nil, err
+		}
+		scheduled.SetBatchID(batchID)
+	}
+	scheduled.SetSendAt(int(at.Unix()))
+	result, err := cl.SendMailWithContext(ctx, &scheduled)
+	if result == nil {
+		return nil, err
+	}
+	result.BatchID = scheduled.BatchID
+	if err == nil {
+		err = CheckResponse(result.Response)
+	}
+	return result, err
+}
+
+// PauseBatch holds the scheduled sends of a batch until it is resumed
+func (cl *Client) PauseBatch(ctx context.Context, batchID string) error {
+	return cl.setBatchStatus(ctx, batchID, ScheduleStatusPause)
+}
+
+// CancelBatch cancels the scheduled sends of a batch. Cancelled messages
+// are discarded when they are due; resuming the batch before then undoes
+// the cancellation.
+func (cl *Client) CancelBatch(ctx context.Context, batchID string) error {
+	return cl.setBatchStatus(ctx, batchID, ScheduleStatusCancel)
+}
+
+// ResumeBatch lets the scheduled sends of a paused or cancelled batch go
+// out at their scheduled time
+func (cl *Client) ResumeBatch(ctx context.Context, batchID string) error {
+	_, err := cl.doJSON(ctx, rest.Delete, "/v3/user/scheduled_sends/"+url.PathEscape(batchID), nil, nil, nil)
+	return err
+}
+
+// ListScheduledSends lists the batches that are paused or cancelled
+func (cl *Client) ListScheduledSends(ctx context.Context) ([]ScheduledSend, error) {
+	var sends []ScheduledSend
+	if _, err := cl.doJSON(ctx, rest.Get, "/v3/user/scheduled_sends", nil, nil, &sends); err != nil {
+		return nil, err
+	}
+	return sends, nil
+}
+
+// GetScheduledSend returns the status of a batch, or nil if it is neither
+// paused nor cancelled
+func (cl *Client) GetScheduledSend(ctx context.Context, batchID string) (*ScheduledSend, error) {
+	var sends []ScheduledSend
+	if _, err := cl.doJSON(ctx, rest.Get, "/v3/user/scheduled_sends/"+url.PathEscape(batchID), nil, nil, &sends); err != nil {
+		return nil, err
+	}
+	for i := range sends {
+		if sends[i].BatchID == batchID {
+			return &sends[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// setBatchStatus creates the status of a batch, or updates it if the batch
+// is already paused or cancelled
+func (cl *Client) setBatchStatus(ctx context.Context, batchID string, status ScheduleStatus) error {
+	if batchID == "" {
+		return fmt.Errorf("sendgrid: a batch ID is required to %s a batch", status)
+	}
+	current, err := cl.GetScheduledSend(ctx, batchID)
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		_, err = cl.doJSON(ctx, rest.Post, "/v3/user/scheduled_sends", nil, &ScheduledSend{BatchID: batchID, Status: status}, nil)
+		return err
+	}
+	if current.Status == status {
+		return nil
+	}
+	_, err = cl.doJSON(ctx, rest.Patch, "/v3/user/scheduled_sends/"+url.PathEscape(batchID), nil, map[string]ScheduleStatus{"status": status}, nil)
+	return err
+}
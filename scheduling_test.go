@@ -0,0 +1,208 @@
+package sendgrid
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sendgrid/sendgrid-go/helpers/mail"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeScheduler emulates the mail/batch and scheduled_sends endpoints
+type fakeScheduler struct {
+	*httptest.Server
+	mu       sync.Mutex
+	statuses map[string]ScheduleStatus
+	sent     []*mail.SGMailV3
+	methods  []string
+}
+
+func newFakeScheduler() *fakeScheduler {
+	s := &fakeScheduler{statuses: make(map[string]ScheduleStatus)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+func (s *fakeScheduler) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.methods = append(s.methods, r.Method+" "+r.URL.Path)
+	id := strings.TrimPrefix(r.URL.Path, "/v3/user/scheduled_sends/")
+	switch {
+	case r.Method == "POST" && r.URL.Path == "/v3/mail/batch":
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"batch_id":"HkJ5yLYULb7Rj8GKSx7u025ouWVlMgAi"}`)
+	case r.Method == "GET" && r.URL.Path == "/v3/mail/batch/HkJ5yLYULb7Rj8GKSx7u025ouWVlMgAi":
+		fmt.Fprint(w, `{"batch_id":"HkJ5yLYULb7Rj8GKSx7u025ouWVlMgAi"}`)
+	case strings.HasPrefix(r.URL.Path, "/v3/mail/batch/"):
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"errors":[{"field":null,"message":"invalid batch id"}]}`)
+	case r.URL.Path == "/v3/mail/send":
+		m, _ := mail.ParseRequestBody(readBody(r))
+		if m.Subject == "rejected" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"errors":[{"field":"subject","message":"rejected"}]}`)
+			return
+		}
+		s.sent = append(s.sent, m)
+		w.WriteHeader(http.StatusAccepted)
+	case r.Method == "POST" && r.URL.Path == "/v3/user/scheduled_sends":
+		var send ScheduledSend
+		json.Unmarshal(readBody(r), &send)
+		if _, ok := s.statuses[send.BatchID]; ok {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.statuses[send.BatchID] = send.Status
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(send)
+	case r.Method == "GET" && r.URL.Path == "/v3/user/scheduled_sends":
+		sends := []ScheduledSend{}
+		for id, status := range s.statuses {
+			sends = append(sends, ScheduledSend{BatchID: id, Status: status})
+		}
+		json.NewEncoder(w).Encode(sends)
+	case r.Method == "GET":
+		sends := []ScheduledSend{}
+		if status, ok := s.statuses[id]; ok {
+			sends = append(sends, ScheduledSend{BatchID: id, Status: status})
+		}
+		json.NewEncoder(w).Encode(sends)
+	case r.Method == "PATCH":
+		var update ScheduledSend
+		json.Unmarshal(readBody(r), &update)
+		s.statuses[id] = update.Status
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == "DELETE":
+		delete(s.statuses, id)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func readBody(r *http.Request) []byte {
+	b, _ := io.ReadAll(r.Body)
+	return b
+}
+
+func TestValidateSendAt(t *testing.T) {
+	assert.Nil(t, ValidateSendAt(time.Now().Add(time.Hour)))
+	assert.Nil(t, ValidateSendAt(time.Now().Add(MaxScheduleAhead-time.Minute)))
+	assert.Equal(t, ErrScheduleWindow, ValidateSendAt(time.Now().Add(-time.Minute)))
+	assert.Equal(t, ErrScheduleWindow, ValidateSendAt(time.Now().Add(MaxScheduleAhead+time.Minute)))
+}
+
+func TestScheduleSend(t *testing.T) {
+	server := newFakeScheduler()
+	defer server.Close()
+	client := New("SENDGRID_APIKEY", WithHost(server.URL))
+	ctx := context.Background()
+
+	at := time.Now().Add(2 * time.Hour)
+	email := testEmail()
+	result, err := client.ScheduleSend(ctx, email, at)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, http.StatusAccepted, result.StatusCode)
+	batchID := result.BatchID
+	assert.Equal(t, "HkJ5yLYULb7Rj8GKSx7u025ouWVlMgAi", batchID)
+	assert.Equal(t, "", email.BatchID, "The message should not be modified")
+	assert.Equal(t, 0, email.SendAt)
+	if assert.Equal(t, 1, len(server.sent)) {
+		assert.Equal(t, batchID, server.sent[0].BatchID)
+		assert.Equal(t, int(at.Unix()), server.sent[0].SendAt)
+	}
+	assert.Nil(t, client.ValidateBatchID(ctx, batchID))
+	assert.True(t, IsBadRequest(client.ValidateBatchID(ctx, "unknown")))
+
+	assert.Nil(t, client.PauseBatch(ctx, batchID))
+	send, err := client.GetScheduledSend(ctx, batchID)
+	assert.Nil(t, err)
+	assert.Equal(t, &ScheduledSend{BatchID: batchID, Status: ScheduleStatusPause}, send)
+
+	assert.Nil(t, client.CancelBatch(ctx, batchID), "A paused batch should be updated")
+	assert.Nil(t, client.CancelBatch(ctx, batchID), "Cancelling twice should be a no-op")
+	sends, err := client.ListScheduledSends(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, []ScheduledSend{{BatchID: batchID, Status: ScheduleStatusCancel}}, sends)
+
+	assert.Nil(t, client.ResumeBatch(ctx, batchID))
+	send, err = client.GetScheduledSend(ctx, batchID)
+	assert.Nil(t, err)
+	assert.Nil(t, send)
+
+	assert.Contains(t, server.methods, "PATCH /v3/user/scheduled_sends/"+batchID)
+	assert.Contains(t, server.methods, "DELETE /v3/user/scheduled_sends/"+batchID)
+}
+
+func TestScheduleSend_outsideWindow(t *testing.T) {
+	server := newFakeScheduler()
+	defer server.Close()
+	client := New("SENDGRID_APIKEY", WithHost(server.URL))
+
+	_, err := client.ScheduleSend(context.Background(), testEmail(), time.Now().Add(73*time.Hour))
+	assert.Equal(t, ErrScheduleWindow, err)
+	assert.Equal(t, 0, len(server.methods), "Nothing should be sent")
+}
+
+func TestScheduleSend_existingBatch(t *testing.T) {
+	server := newFakeScheduler()
+	defer server.Close()
+	client := New("SENDGRID_APIKEY", WithHost(server.URL))
+
+	email := testEmail().SetBatchID("EXISTING_BATCH_ID")
+	result, err := client.ScheduleSend(context.Background(), email, time.Now().Add(time.Hour))
+	assert.Nil(t, err)
+	assert.Equal(t, "EXISTING_BATCH_ID", result.BatchID)
+	assert.Equal(t, []string{"POST /v3/mail/send"}, server.methods)
+	assert.NotNil(t, client.PauseBatch(context.Background(), ""))
+}
+
+func TestScheduleSend_rejected(t *testing.T) {
+	server := newFakeScheduler()
+	defer server.Close()
+	client := New("SENDGRID_APIKEY", WithHost(server.URL))
+
+	email := testEmail().SetBatchID("EXISTING_BATCH_ID")
+	email.Subject = "rejected"
+	result, err := client.ScheduleSend(context.Background(), email, time.Now().Add(time.Hour))
+	assert.True(t, IsBadRequest(err), "A rejected send should return an *APIError without ReturnAPIErrors")
+	if assert.NotNil(t, result) {
+		assert.Equal(t, http.StatusBadRequest, result.StatusCode)
+		assert.Equal(t, "rejected", result.Errors[0].Message)
+	}
+}
+
+func TestScheduleSend_sendClientBaseURL(t *testing.T) {
+	server := newFakeScheduler()
+	defer server.Close()
+	client := NewSendClient("SENDGRID_APIKEY")
+	client.Request.BaseURL = server.URL + "/v3/mail/send"
+	ctx := context.Background()
+
+	result, err := client.ScheduleSend(ctx, testEmail(), time.Now().Add(time.Hour))
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Nil(t, client.CancelBatch(ctx, result.BatchID), "The batch should be cancelled on the host of the sends")
+	sends, err := client.ListScheduledSends(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, []ScheduledSend{{BatchID: result.BatchID, Status: ScheduleStatusCancel}}, sends)
+	assert.Equal(t, []string{
+		"POST /v3/mail/batch",
+		"POST /v3/mail/send",
+		"GET /v3/user/scheduled_sends/" + result.BatchID,
+		"POST /v3/user/scheduled_sends",
+		"GET /v3/user/scheduled_sends",
+	}, server.methods)
+}
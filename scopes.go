@@ -0,0 +1,319 @@
+package sendgrid
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/sendgrid/rest"
+)
+
+// Scopes is a set of API key scopes, e.g. "mail.send"
+type Scopes []string
+
+// Scope presets for common uses of the library
+var (
+	// MailSendScopes can send mail
+	MailSendScopes = Scopes{"mail.send"}
+	// SchedulingScopes can schedule sends and pause, cancel or resume them
+	SchedulingScopes = Scopes{
+		"mail.send",
+		"mail.batch.create",
+		"mail.batch.read",
+		"user.scheduled_sends.create",
+		"user.scheduled_sends.read",
+		"user.scheduled_sends.update",
+		"user.scheduled_sends.delete",
+	}
+	// APIKeyAdminScopes can manage API keys
+	APIKeyAdminScopes = Scopes{
+		"api_keys.create",
+		"api_keys.read",
+		"api_keys.update",
+		"api_keys.delete",
+	}
+	// EventWebhookScopes can read the settings of the Event Webhook
+	EventWebhookScopes = Scopes{
+		"user.webhooks.event.settings.read",
+	}
+	// SuppressionReadScopes can list every kind of suppressions
+	SuppressionReadScopes = Scopes{
+		"asm.suppressions.global.read",
+		"suppression.blocks.read",
+		"suppression.bounces.read",
+		"suppression.invalid_emails.read",
+		"suppression.spam_reports.read",
+		"suppression.unsubscribes.read",
+	}
+)
+
+// endpointScopes are the scopes needed by the endpoints the library calls,
+// keyed by method and path, where {} matches one path segment
+var endpointScopes = map[string]Scopes{
+	"POST /v3/mail/send":    {"mail.send"},
+	"POST /v3/mail/batch":   {"mail.batch.create"},
+	"GET /v3/mail/batch/{}": {"mail.batch.read"},
+
+	"GET /v3/user/scheduled_sends":       {"user.scheduled_sends.read"},
+	"POST /v3/user/scheduled_sends":      {"user.scheduled_sends.create"},
+	"GET /v3/user/scheduled_sends/{}":    {"user.scheduled_sends.read"},
+	"PATCH /v3/user/scheduled_sends/{}":  {"user.scheduled_sends.update"},
+	"DELETE /v3/user/scheduled_sends/{}": {"user.scheduled_sends.delete"},
+
+	"GET /v3/user/webhooks/event/settings/signed": {"user.webhooks.event.settings.read"},
+	"GET /v3/scopes": {},
+
+	"POST /v3/api_keys":      {"api_keys.create"},
+	"GET /v3/api_keys":       {"api_keys.read"},
+	"GET /v3/api_keys/{}":    {"api_keys.read"},
+	"PUT /v3/api_keys/{}":    {"api_keys.update"},
+	"PATCH /v3/api_keys/{}":  {"api_keys.update"},
+	"DELETE /v3/api_keys/{}": {"api_keys.delete"},
+
+	"GET /v3/suppression/blocks":               {"suppression.blocks.read"},
+	"DELETE /v3/suppression/blocks":            {"suppression.blocks.delete"},
+	"GET /v3/suppression/blocks/{}":            {"suppression.blocks.read"},
+	"DELETE /v3/suppression/blocks/{}":         {"suppression.blocks.delete"},
+	"GET /v3/suppression/bounces":              {"suppression.bounces.read"},
+	"DELETE /v3/suppression/bounces":           {"suppression.bounces.delete"},
+	"GET /v3/suppression/bounces/{}":           {"suppression.bounces.read"},
+	"DELETE /v3/suppression/bounces/{}":        {"suppression.bounces.delete"},
+	"GET /v3/suppression/invalid_emails":       {"suppression.invalid_emails.read"},
+	"DELETE /v3/suppression/invalid_emails":    {"suppression.invalid_emails.delete"},
+	"GET /v3/suppression/invalid_emails/{}":    {"suppression.invalid_emails.read"},
+	"DELETE /v3/suppression/invalid_emails/{}": {"suppression.invalid_emails.delete"},
+	"GET /v3/suppression/spam_reports":         {"suppression.spam_reports.read"},
+	"DELETE /v3/suppression/spam_reports":      {"suppression.spam_reports.delete"},
+	"GET /v3/suppression/spam_report/{}":       {"suppression.spam_reports.read"},
+	"DELETE /v3/suppression/spam_report/{}":    {"suppression.spam_reports.delete"},
+	"GET /v3/suppression/unsubscribes":         {"suppression.unsubscribes.read"},
+
+	"POST /v3/asm/suppressions/global":           {"asm.suppressions.global.create"},
+	"GET /v3/asm/suppressions/global/{}":         {"asm.suppressions.global.read"},
+	"DELETE /v3/asm/suppressions/global/{}":      {"asm.suppressions.global.delete"},
+	"GET /v3/asm/suppressions":                   {"asm.groups.suppressions.read"},
+	"GET /v3/asm/suppressions/{}":                {"asm.groups.suppressions.read"},
+	"POST /v3/asm/groups":                        {"asm.groups.create"},
+	"GET /v3/asm/groups":                         {"asm.groups.read"},
+	"GET /v3/asm/groups/{}":                      {"asm.groups.read"},
+	"PATCH /v3/asm/groups/{}":                    {"asm.groups.update"},
+	"DELETE /v3/asm/groups/{}":                   {"asm.groups.delete"},
+	"POST /v3/asm/groups/{}/suppressions":        {"asm.groups.suppressions.create"},
+	"GET /v3/asm/groups/{}/suppressions":         {"asm.groups.suppressions.read"},
+	"POST /v3/asm/groups/{}/suppressions/search": {"asm.groups.suppressions.read"},
+	"DELETE /v3/asm/groups/{}/suppressions/{}":   {"asm.groups.suppressions.delete"},
+}
+
+// callEndpoints are the endpoints each call of the library makes requests
+// to, as keys of endpointScopes
+var callEndpoints = map[string][]string{
+	"Send":                {"POST /v3/mail/send"},
+	"SendWithContext":     {"POST /v3/mail/send"},
+	"SendMail":            {"POST /v3/mail/send"},
+	"SendMailWithContext": {"POST /v3/mail/send"},
+	// the SMTP relay authorizes keys with the scope of mail/send
+	"SMTPSender.SendMailWithContext": {"POST /v3/mail/send"},
+	// BulkSend and ScheduleSend create a batch ID unless the message has one
+	"BulkSend":              {"POST /v3/mail/send", "POST /v3/mail/batch"},
+	"ScheduleSend":          {"POST /v3/mail/send", "POST /v3/mail/batch"},
+	"CreateBatchID":         {"POST /v3/mail/batch"},
+	"ValidateBatchID":       {"GET /v3/mail/batch/{}"},
+	"PauseBatch":            {"GET /v3/user/scheduled_sends/{}", "POST /v3/user/scheduled_sends", "PATCH /v3/user/scheduled_sends/{}"},
+	"CancelBatch":           {"GET /v3/user/scheduled_sends/{}", "POST /v3/user/scheduled_sends", "PATCH /v3/user/scheduled_sends/{}"},
+	"ResumeBatch":           {"DELETE /v3/user/scheduled_sends/{}"},
+	"ListScheduledSends":    {"GET /v3/user/scheduled_sends"},
+	"GetScheduledSend":      {"GET /v3/user/scheduled_sends/{}"},
+	"EventWebhookPublicKey": {"GET /v3/user/webhooks/event/settings/signed"},
+	"GrantedScopes":         {"GET /v3/scopes"},
+	"APIKeys.Create":        {"POST /v3/api_keys"},
+	"APIKeys.List":          {"GET /v3/api_keys"},
+	"APIKeys.Get":           {"GET /v3/api_keys/{}"},
+	"APIKeys.Update":        {"PUT /v3/api_keys/{}"},
+	"APIKeys.Rename":        {"PATCH /v3/api_keys/{}"},
+	"APIKeys.Delete":        {"DELETE /v3/api_keys/{}"},
+
+	"Suppressions.Blocks.List":                {"GET /v3/suppression/blocks"},
+	"Suppressions.Blocks.Iterate":             {"GET /v3/suppression/blocks"},
+	"Suppressions.Blocks.Get":                 {"GET /v3/suppression/blocks/{}"},
+	"Suppressions.Blocks.Delete":              {"DELETE /v3/suppression/blocks", "DELETE /v3/suppression/blocks/{}"},
+	"Suppressions.Blocks.DeleteAll":           {"DELETE /v3/suppression/blocks"},
+	"Suppressions.Bounces.List":               {"GET /v3/suppression/bounces"},
+	"Suppressions.Bounces.Iterate":            {"GET /v3/suppression/bounces"},
+	"Suppressions.Bounces.Get":                {"GET /v3/suppression/bounces/{}"},
+	"Suppressions.Bounces.Delete":             {"DELETE /v3/suppression/bounces", "DELETE /v3/suppression/bounces/{}"},
+	"Suppressions.Bounces.DeleteAll":          {"DELETE /v3/suppression/bounces"},
+	"Suppressions.InvalidEmails.List":         {"GET /v3/suppression/invalid_emails"},
+	"Suppressions.InvalidEmails.Iterate":      {"GET /v3/suppression/invalid_emails"},
+	"Suppressions.InvalidEmails.Get":          {"GET /v3/suppression/invalid_emails/{}"},
+	"Suppressions.InvalidEmails.Delete":       {"DELETE /v3/suppression/invalid_emails", "DELETE /v3/suppression/invalid_emails/{}"},
+	"Suppressions.InvalidEmails.DeleteAll":    {"DELETE /v3/suppression/invalid_emails"},
+	"Suppressions.SpamReports.List":           {"GET /v3/suppression/spam_reports"},
+	"Suppressions.SpamReports.Iterate":        {"GET /v3/suppression/spam_reports"},
+	"Suppressions.SpamReports.Get":            {"GET /v3/suppression/spam_report/{}"},
+	"Suppressions.SpamReports.Delete":         {"DELETE /v3/suppression/spam_reports", "DELETE /v3/suppression/spam_report/{}"},
+	"Suppressions.SpamReports.DeleteAll":      {"DELETE /v3/suppression/spam_reports"},
+	"Suppressions.GlobalUnsubscribes.List":    {"GET /v3/suppression/unsubscribes"},
+	"Suppressions.GlobalUnsubscribes.Iterate": {"GET /v3/suppression/unsubscribes"},
+	"Suppressions.GlobalUnsubscribes.Get":     {"GET /v3/asm/suppressions/global/{}"},
+	"Suppressions.GlobalUnsubscribes.Delete":  {"DELETE /v3/asm/suppressions/global/{}"},
+
+	"ASM.CreateGroup":             {"POST /v3/asm/groups"},
+	"ASM.ListGroups":              {"GET /v3/asm/groups"},
+	"ASM.GetGroup":                {"GET /v3/asm/groups/{}"},
+	"ASM.UpdateGroup":             {"PATCH /v3/asm/groups/{}"},
+	"ASM.DeleteGroup":             {"DELETE /v3/asm/groups/{}"},
+	"ASM.AddGroupSuppressions":    {"POST /v3/asm/groups/{}/suppressions"},
+	"ASM.ListGroupSuppressions":   {"GET /v3/asm/groups/{}/suppressions"},
+	"ASM.SearchGroupSuppressions": {"POST /v3/asm/groups/{}/suppressions/search"},
+	"ASM.RemoveGroupSuppression":  {"DELETE /v3/asm/groups/{}/suppressions/{}"},
+	"ASM.ListSuppressions":        {"GET /v3/asm/suppressions"},
+	"ASM.SuppressedGroups":        {"GET /v3/asm/suppressions/{}"},
+	"ASM.AddGlobalSuppressions":   {"POST /v3/asm/suppressions/global"},
+	"ASM.IsGloballySuppressed":    {"GET /v3/asm/suppressions/global/{}"},
+	"ASM.DeleteGlobalSuppression": {"DELETE /v3/asm/suppressions/global/{}"},
+}
+
+// RequiredScopes returns the scopes needed by the given calls of the
+// library, named after their method, e.g. "ScheduleSend", prefixed by
+// their service, e.g. "APIKeys.Create". Requests made with MakeRequest
+// are looked up with EndpointScopes.
+func RequiredScopes(calls ...string) (Scopes, error) {
+	var required Scopes
+	for _, call := range calls {
+		endpoints, ok := callEndpoints[call]
+		if !ok {
+			return nil, fmt.Errorf("sendgrid: unknown call %q", call)
+		}
+		for _, endpoint := range endpoints {
+			required = required.Union(endpointScopes[endpoint])
+		}
+	}
+	return required, nil
+}
+
+// EndpointScopes returns the scopes a request to the endpoint at path,
+// e.g. "/v3/api_keys/abc", needs. ok is false for endpoints the library
+// does not call.
+func EndpointScopes(method rest.Method, path string) (scopes Scopes, ok bool) {
+	if i := strings.Index(path, "/v3/"); i > 0 {
+		path = path[i:]
+	}
+	if scopes, ok := endpointScopes[string(method)+" "+path]; ok {
+		return scopes, true
+	}
+	segments := strings.Split(path, "/")
+	wildcards := len(segments) + 1
+	for key, endpointScopes := range endpointScopes {
+		n, matched := matchEndpoint(key, string(method), segments)
+		if matched && n < wildcards {
+			scopes, ok, wildcards = endpointScopes, true, n
+		}
+	}
+	return scopes, ok
+}
+
+// matchEndpoint reports whether the endpointScopes key matches method and
+// the segments of a path, and the number of {} segments the match used
+func matchEndpoint(key, method string, segments []string) (int, bool) {
+	i := strings.IndexByte(key, ' ')
+	if key[:i] != method {
+		return 0, false
+	}
+	pattern := strings.Split(key[i+1:], "/")
+	if len(pattern) != len(segments) {
+		return 0, false
+	}
+	wildcards := 0
+	for j, segment := range pattern {
+		switch {
+		case segment == "{}" && segments[j] != "":
+			wildcards++
+		case segment != segments[j]:
+			return 0, false
+		}
+	}
+	return wildcards, true
+}
+
+// requestScopes returns the scopes request needs, from its method and the
+// path of its BaseURL
+func requestScopes(request rest.Request) Scopes {
+	u, err := url.Parse(request.BaseURL)
+	if err != nil {
+		return nil
+	}
+	scopes, _ := EndpointScopes(request.Method, u.EscapedPath())
+	return scopes
+}
+
+// Contains reports whether s holds scope
+func (s Scopes) Contains(scope string) bool {
+	for _, granted := range s {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Union returns the sorted scopes of s and other, without duplicates
+func (s Scopes) Union(other Scopes) Scopes {
+	union := make(Scopes, 0, len(s)+len(other))
+	union = append(union, s...)
+	union = append(union, other...)
+	return union.normalize()
+}
+
+// Missing returns the scopes of required that s does not hold, sorted
+func (s Scopes) Missing(required Scopes) Scopes {
+	return required.without(s)
+}
+
+// Extra returns the scopes of s that required does not hold, sorted. A
+// key with extra scopes is over-privileged for the calls needing
+// required.
+func (s Scopes) Extra(required Scopes) Scopes {
+	return s.without(required)
+}
+
+// Covers reports whether s holds every scope of required
+func (s Scopes) Covers(required Scopes) bool {
+	return len(s.Missing(required)) == 0
+}
+
+// without returns the sorted scopes of s that are not in other
+func (s Scopes) without(other Scopes) Scopes {
+	var diff Scopes
+	for _, scope := range s.normalize() {
+		if !other.Contains(scope) {
+			diff = append(diff, scope)
+		}
+	}
+	return diff
+}
+
+// normalize returns the scopes of s sorted, without duplicates
+func (s Scopes) normalize() Scopes {
+	sorted := append(Scopes(nil), s...)
+	sort.Strings(sorted)
+	normalized := sorted[:0]
+	for i, scope := range sorted {
+		if i == 0 || scope != sorted[i-1] {
+			normalized = append(normalized, scope)
+		}
+	}
+	return normalized
+}
+
+// GrantedScopes returns the scopes of the API key of the client
+func (cl *Client) GrantedScopes(ctx context.Context) (Scopes, error) {
+	var granted struct {
+		Scopes Scopes `json:"scopes"`
+	}
+	if _, err := cl.doJSON(ctx, rest.Get, "/v3/scopes", nil, nil, &granted); err != nil {
+		return nil, err
+	}
+	return granted.Scopes, nil
+}
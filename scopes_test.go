@@ -0,0 +1,199 @@
+package sendgrid
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/sendgrid/rest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequiredScopes(t *testing.T) {
+	required, err := RequiredScopes("ScheduleSend", "CancelBatch", "ResumeBatch")
+	assert.Nil(t, err)
+	assert.Equal(t, Scopes{
+		"mail.batch.create",
+		"mail.send",
+		"user.scheduled_sends.create",
+		"user.scheduled_sends.delete",
+		"user.scheduled_sends.read",
+		"user.scheduled_sends.update",
+	}, required)
+	assert.True(t, SchedulingScopes.Covers(required))
+
+	required, err = RequiredScopes("Send", "SendWithContext")
+	assert.Nil(t, err)
+	assert.Equal(t, MailSendScopes, required)
+
+	_, err = RequiredScopes("Send", "SendFax")
+	assert.NotNil(t, err)
+
+	for call, endpoints := range callEndpoints {
+		_, err := RequiredScopes(call)
+		assert.Nil(t, err, call)
+		for _, endpoint := range endpoints {
+			_, ok := endpointScopes[endpoint]
+			assert.True(t, ok, call+" calls an endpoint without scopes: "+endpoint)
+		}
+	}
+}
+
+// notCalls are the exported methods of services that make no request of
+// their own, or whose request is chosen by the caller
+var notCalls = map[string]bool{
+	"APIKeys":                true,
+	"ASM":                    true,
+	"Suppressions":           true,
+	"GetRequest":             true,
+	"MakeRequest":            true,
+	"MakeRequestWithContext": true,
+	// global unsubscribes can not be deleted at once
+	"Suppressions.GlobalUnsubscribes.DeleteAll": true,
+}
+
+// exportedMethods returns the names of the exported methods of v, prefixed
+func exportedMethods(prefix string, v interface{}) []string {
+	var names []string
+	t := reflect.TypeOf(v)
+	for i := 0; i < t.NumMethod(); i++ {
+		names = append(names, prefix+t.Method(i).Name)
+	}
+	return names
+}
+
+func TestRequiredScopes_everyCall(t *testing.T) {
+	cl := New("SENDGRID_APIKEY")
+	calls := exportedMethods("", cl)
+	calls = append(calls, exportedMethods("APIKeys.", cl.APIKeys())...)
+	calls = append(calls, exportedMethods("ASM.", cl.ASM())...)
+	calls = append(calls, exportedMethods("SMTPSender.", NewSMTPSender("SENDGRID_APIKEY"))...)
+
+	// every accessor of SuppressionsService returning a list is a kind
+	suppressions := reflect.ValueOf(cl.Suppressions())
+	lists := 0
+	for i := 0; i < suppressions.NumMethod(); i++ {
+		method := suppressions.Type().Method(i)
+		if method.Type.NumIn() != 1 || method.Type.NumOut() != 1 || method.Type.Out(0) != reflect.TypeOf(&SuppressionList{}) {
+			continue
+		}
+		list := suppressions.Method(i).Call(nil)[0].Interface()
+		for _, call := range exportedMethods("Suppressions."+method.Name+".", list) {
+			if call != "Suppressions."+method.Name+".Kind" {
+				calls = append(calls, call)
+			}
+		}
+		lists++
+	}
+	assert.Equal(t, len(SuppressionKinds), lists)
+
+	for _, call := range calls {
+		if notCalls[call] {
+			continue
+		}
+		_, ok := callEndpoints[call]
+		assert.True(t, ok, "No scopes for "+call)
+	}
+}
+
+func TestEndpointScopes(t *testing.T) {
+	tests := []struct {
+		method rest.Method
+		path   string
+		scopes Scopes
+	}{
+		{rest.Post, "/v3/mail/send", Scopes{"mail.send"}},
+		{rest.Get, "/v3/api_keys", Scopes{"api_keys.read"}},
+		{rest.Delete, "/v3/api_keys/abc", Scopes{"api_keys.delete"}},
+		{rest.Get, "https://api.sendgrid.com/v3/asm/groups/42/suppressions", Scopes{"asm.groups.suppressions.read"}},
+		{rest.Get, "/v3/asm/suppressions/global/user%40example.com", Scopes{"asm.suppressions.global.read"}},
+		{rest.Get, "/v3/asm/suppressions/user%40example.com", Scopes{"asm.groups.suppressions.read"}},
+		{rest.Post, "/v3/asm/suppressions/global", Scopes{"asm.suppressions.global.create"}},
+	}
+	for _, test := range tests {
+		scopes, ok := EndpointScopes(test.method, test.path)
+		assert.True(t, ok, test.path)
+		assert.Equal(t, test.scopes, scopes, test.path)
+	}
+
+	_, ok := EndpointScopes(rest.Get, "/v3/api_keys/")
+	assert.False(t, ok)
+	_, ok = EndpointScopes(rest.Get, "/v3/alerts")
+	assert.False(t, ok)
+}
+
+func TestRequiredScopes_forbidden(t *testing.T) {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"errors":[{"field":null,"message":"access forbidden"}]}`)
+	}))
+	defer fakeServer.Close()
+	// with ReturnAPIErrors or a RetryPolicy, MakeRequest returns the
+	// *APIError itself
+	withErrors := New("SENDGRID_APIKEY", WithHost(fakeServer.URL), WithRetryPolicy(testRetryPolicy()))
+	withErrors.ReturnAPIErrors = true
+	for _, client := range []*Client{New("SENDGRID_APIKEY", WithHost(fakeServer.URL)), withErrors} {
+		_, err := client.APIKeys().Get(context.Background(), "abc")
+		var apiErr *APIError
+		if assert.True(t, errors.As(err, &apiErr)) {
+			assert.Equal(t, Scopes{"api_keys.read"}, apiErr.RequiredScopes)
+		}
+
+		_, err = client.APIKeys().List(context.Background())
+		apiErr = nil
+		if assert.True(t, errors.As(err, &apiErr)) {
+			assert.Equal(t, Scopes{"api_keys.read"}, apiErr.RequiredScopes)
+		}
+
+		it := client.Suppressions().Blocks().Iterate(context.Background(), nil)
+		assert.False(t, it.Next())
+		apiErr = nil
+		if assert.True(t, errors.As(it.Err(), &apiErr)) {
+			assert.Equal(t, Scopes{"suppression.blocks.read"}, apiErr.RequiredScopes)
+		}
+	}
+}
+
+func TestScopes(t *testing.T) {
+	granted := Scopes{"mail.send", "api_keys.read", "mail.send", "alerts.read"}
+	required := Scopes{"mail.send", "mail.batch.create"}
+
+	assert.True(t, granted.Contains("alerts.read"))
+	assert.False(t, granted.Contains("alerts"))
+	assert.Equal(t, Scopes{"mail.batch.create"}, granted.Missing(required))
+	assert.Equal(t, Scopes{"alerts.read", "api_keys.read"}, granted.Extra(required), "Extra scopes make a key over-privileged")
+	assert.False(t, granted.Covers(required))
+	assert.True(t, granted.Covers(MailSendScopes))
+	assert.Nil(t, MailSendScopes.Extra(MailSendScopes))
+	assert.Equal(t, Scopes{"alerts.read", "api_keys.read", "mail.batch.create", "mail.send"}, granted.Union(required))
+	assert.Equal(t, Scopes{"mail.send", "api_keys.read", "mail.send", "alerts.read"}, granted, "Scopes should not be modified")
+}
+
+func TestGrantedScopes(t *testing.T) {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v3/scopes", r.URL.Path)
+		fmt.Fprint(w, `{"scopes":["mail.send","alerts.read"]}`)
+	}))
+	defer fakeServer.Close()
+
+	granted, err := New("SENDGRID_APIKEY", WithHost(fakeServer.URL)).GrantedScopes(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, Scopes{"mail.send", "alerts.read"}, granted)
+
+	required, _ := RequiredScopes("Send")
+	assert.Equal(t, Scopes{"alerts.read"}, granted.Extra(required))
+}
+
+func TestSuppressionReadScopes(t *testing.T) {
+	var calls []string
+	for _, list := range []string{"Blocks", "Bounces", "InvalidEmails", "SpamReports", "GlobalUnsubscribes"} {
+		calls = append(calls, "Suppressions."+list+".List", "Suppressions."+list+".Iterate", "Suppressions."+list+".Get")
+	}
+	required, err := RequiredScopes(calls...)
+	assert.Nil(t, err)
+	assert.Equal(t, SuppressionReadScopes, required)
+}
@@ -0,0 +1,88 @@
+package sendgrid
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/sendgrid/rest"
+	"github.com/sendgrid/sendgrid-go/helpers/mail"
+)
+
+// messageIDHeader is the response header carrying the ID SendGrid assigned
+// to an accepted message, as found in Event Webhook sg_message_id values
+const messageIDHeader = "X-Message-Id"
+
+// RateLimit holds the rate limit state reported with a response. Fields are
+// zero when the response has no rate limit headers.
+type RateLimit struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
+// ParseRateLimit reads the X-RateLimit-* headers of a response
+func ParseRateLimit(response *rest.Response) RateLimit {
+	var rl RateLimit
+	if response == nil {
+		return rl
+	}
+	if v, err := strconv.Atoi(headerValue(response.Headers, "X-RateLimit-Limit")); err == nil {
+		rl.Limit = v
+	}
+	if v, err := strconv.Atoi(headerValue(response.Headers, "X-RateLimit-Remaining")); err == nil {
+		rl.Remaining = v
+	}
+	if v, err := strconv.ParseInt(headerValue(response.Headers, "X-RateLimit-Reset"), 10, 64); err == nil {
+		rl.Reset = time.Unix(v, 0)
+	}
+	return rl
+}
+
+// SendResult describes the outcome of a mail/send request
+type SendResult struct {
+	StatusCode int
+	// MessageID is empty unless the message was accepted
+	MessageID string
+	// MessageIDs lists the ID of each message accepted by the SMTP relay,
+	// which sends one per personalization. It is nil for mail/send.
+	MessageIDs []string
+	// BatchID is the batch of a send made by ScheduleSend
+	BatchID   string
+	RateLimit RateLimit
+	// Errors is decoded from the body of non-2xx responses
+	Errors   []ErrorDetail
+	Response *rest.Response
+}
+
+// NewSendResult builds a SendResult from a mail/send response
+func NewSendResult(response *rest.Response) *SendResult {
+	result := &SendResult{
+		StatusCode: response.StatusCode,
+		MessageID:  headerValue(response.Headers, messageIDHeader),
+		RateLimit:  ParseRateLimit(response),
+		Response:   response,
+	}
+	var apiErr *APIError
+	if errors.As(CheckResponse(response), &apiErr) {
+		result.Errors = apiErr.Errors
+	}
+	return result
+}
+
+// SendMail sends an email through Twilio SendGrid like Send, returning a
+// typed result instead of the raw response
+func (cl *Client) SendMail(email *mail.SGMailV3) (*SendResult, error) {
+	return cl.SendMailWithContext(context.Background(), email)
+}
+
+// SendMailWithContext is like SendMail, aborting the request if ctx is
+// cancelled or its deadline passes.
+func (cl *Client) SendMailWithContext(ctx context.Context, email *mail.SGMailV3) (*SendResult, error) {
+	response, err := cl.SendWithContext(ctx, email)
+	if response == nil {
+		return nil, err
+	}
+	return NewSendResult(response), err
+}
@@ -0,0 +1,93 @@
+package sendgrid
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sendgrid/rest"
+	"github.com/sendgrid/sendgrid-go/helpers/mail"
+	"github.com/stretchr/testify/assert"
+)
+
+func testEmail() *mail.SGMailV3 {
+	from := mail.NewEmail("Example User", "test@example.com")
+	to := mail.NewEmail("Example User", "test@example.com")
+	return mail.NewSingleEmail(from, "subject", to, "text", "<p>html</p>")
+}
+
+func TestParseRateLimit(t *testing.T) {
+	reset := time.Now().Add(time.Minute).Truncate(time.Second)
+	header := http.Header{}
+	header.Set("X-RateLimit-Limit", "600")
+	header.Set("X-RateLimit-Remaining", "599")
+	header.Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+	rl := ParseRateLimit(&rest.Response{Headers: header})
+	assert.Equal(t, 600, rl.Limit)
+	assert.Equal(t, 599, rl.Remaining)
+	assert.True(t, reset.Equal(rl.Reset))
+
+	assert.Equal(t, RateLimit{}, ParseRateLimit(&rest.Response{}))
+	assert.Equal(t, RateLimit{}, ParseRateLimit(nil))
+}
+
+func TestParseRateLimit_nonCanonicalKeys(t *testing.T) {
+	reset := time.Now().Add(time.Minute).Truncate(time.Second)
+	response := &rest.Response{Headers: map[string][]string{
+		"X-RateLimit-Limit":     {"600"},
+		"x-ratelimit-remaining": {"599"},
+		"X-RATELIMIT-RESET":     {strconv.FormatInt(reset.Unix(), 10)},
+	}}
+	rl := ParseRateLimit(response)
+	assert.Equal(t, 600, rl.Limit)
+	assert.Equal(t, 599, rl.Remaining)
+	assert.True(t, reset.Equal(rl.Reset))
+}
+
+func TestSendMail_accepted(t *testing.T) {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Message-Id", "W7VuvHJuQDqMxaPHzGPwgw")
+		w.Header().Set("X-RateLimit-Remaining", "42")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer fakeServer.Close()
+	client := New("SENDGRID_APIKEY", WithHost(fakeServer.URL))
+	result, err := client.SendMail(testEmail())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusAccepted, result.StatusCode)
+	assert.Equal(t, "W7VuvHJuQDqMxaPHzGPwgw", result.MessageID)
+	assert.Equal(t, 42, result.RateLimit.Remaining)
+	assert.Nil(t, result.Errors)
+	assert.NotNil(t, result.Response)
+}
+
+func TestSendMail_rejected(t *testing.T) {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"errors":[{"message":"Invalid type. Expected: object, given: string.","field":"from","help":null}]}`)
+	}))
+	defer fakeServer.Close()
+	client := New("SENDGRID_APIKEY", WithHost(fakeServer.URL))
+	result, err := client.SendMail(testEmail())
+	assert.Nil(t, err, "Errors should only be returned when opted in")
+	assert.Equal(t, http.StatusBadRequest, result.StatusCode)
+	assert.Equal(t, "", result.MessageID)
+	if assert.Equal(t, 1, len(result.Errors)) {
+		assert.Equal(t, "from", result.Errors[0].Field)
+	}
+
+	client.ReturnAPIErrors = true
+	result, err = client.SendMail(testEmail())
+	assert.True(t, IsBadRequest(err))
+	assert.Equal(t, 1, len(result.Errors), "The result should still be returned")
+}
+
+func TestSendMail_transportError(t *testing.T) {
+	client := New("SENDGRID_APIKEY", WithHost("http://127.0.0.1:0"))
+	result, err := client.SendMail(testEmail())
+	assert.NotNil(t, err)
+	assert.Nil(t, result)
+}
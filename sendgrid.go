@@ -2,12 +2,11 @@
 package sendgrid
 
 import (
-	"errors"
-	"net/http"
-	"strconv"
-	"time"
+	"context"
+	"net/url"
+	"strings"
 
-	"github.com/sendgrid/rest" // depends on version 2.2.0
+	"github.com/sendgrid/rest" // depends on version 2.4.0
 	"github.com/sendgrid/sendgrid-go/helpers/mail"
 )
 
@@ -18,34 +17,66 @@ const (
 	rateLimitSleep = 1100
 )
 
+// mailSendEndpoint is the endpoint of Send
+const mailSendEndpoint = "/v3/mail/send"
+
 // Client is the Twilio SendGrid Go client
 type Client struct {
 	// rest.Request
 	rest.Request
+
+	// ReturnAPIErrors makes Send return an *APIError alongside the
+	// response when the API answers with a non-2xx status code.
+	ReturnAPIErrors bool
+
+	// RetryPolicy, when set, makes Send retry failed requests.
+	RetryPolicy *RetryPolicy
+
+	// ValidateMail makes Send check messages with SGMailV3.Validate and
+	// return the validation errors without making a request.
+	ValidateMail bool
+
+	// options and restClient are set by New; clients built by
+	// NewSendClient send through DefaultClient.
+	options    options
+	restClient *rest.Client
 }
 
 // options for requestNew
 type options struct {
-	Key      string
-	Endpoint string
-	Host     string
-	Subuser  string
+	Key             string
+	Endpoint        string
+	Host            string
+	Subuser         string
+	UserAgentSuffix string
 }
 
 func (o *options) baseURL() string {
 	return o.Host + o.Endpoint
 }
 
+// sendHost returns the host cl.Request sends mail to, which differs from
+// the client's host when BaseURL was changed after the client was built
+func (cl *Client) sendHost() string {
+	if host := strings.TrimSuffix(cl.Request.BaseURL, mailSendEndpoint); host != cl.Request.BaseURL {
+		return host
+	}
+	if u, err := url.Parse(cl.Request.BaseURL); err == nil && u.Host != "" {
+		return u.Scheme + "://" + u.Host
+	}
+	return requestNew(cl.options).BaseURL
+}
+
 // GetRequest
 // @return [Request] a default request object
 func GetRequest(key, endpoint, host string) rest.Request {
-	return requestNew(options{key, endpoint, host, ""})
+	return requestNew(options{Key: key, Endpoint: endpoint, Host: host})
 }
 
 // GetRequestSubuser like GetRequest but with On-Behalf of Subuser
 // @return [Request] a default request object
 func GetRequestSubuser(key, endpoint, host, subuser string) rest.Request {
-	return requestNew(options{key, endpoint, host, subuser})
+	return requestNew(options{Key: key, Endpoint: endpoint, Host: host, Subuser: subuser})
 }
 
 // requestNew create Request
@@ -61,6 +92,10 @@ func requestNew(options options) rest.Request {
 		"Accept":        "application/json",
 	}
 
+	if len(options.UserAgentSuffix) != 0 {
+		requestHeaders["User-Agent"] += " " + options.UserAgentSuffix
+	}
+
 	if len(options.Subuser) != 0 {
 		requestHeaders["On-Behalf-Of"] = options.Subuser
 	}
@@ -73,23 +108,90 @@ func requestNew(options options) rest.Request {
 
 // Send sends an email through Twilio SendGrid
 func (cl *Client) Send(email *mail.SGMailV3) (*rest.Response, error) {
-	cl.Body = mail.GetRequestBody(email)
-	return MakeRequest(cl.Request)
+	return cl.SendWithContext(context.Background(), email)
+}
+
+// SendWithContext sends an email through Twilio SendGrid, aborting the
+// request if ctx is cancelled or its deadline passes. The body is set on a
+// copy of cl.Request, so a client can send from several goroutines.
+func (cl *Client) SendWithContext(ctx context.Context, email *mail.SGMailV3) (*rest.Response, error) {
+	if cl.ValidateMail {
+		if err := email.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	body, err := mail.GetRequestBodyE(email)
+	if err != nil {
+		return nil, err
+	}
+	request := cl.Request
+	request.Body = body
+	return cl.MakeRequestWithContext(ctx, request)
+}
+
+// GetRequest returns a request to the given endpoint on the host of
+// cl.Request, carrying a copy of its headers: the API key, subuser and
+// User-Agent, and any header set on it since the client was built
+// @return [Request] a default request object
+func (cl *Client) GetRequest(endpoint string) rest.Request {
+	if cl.Request.BaseURL == "" {
+		o := cl.options
+		o.Endpoint = endpoint
+		return requestNew(o)
+	}
+	headers := make(map[string]string, len(cl.Request.Headers))
+	for key, value := range cl.Request.Headers {
+		headers[key] = value
+	}
+	return rest.Request{
+		BaseURL: cl.sendHost() + endpoint,
+		Headers: headers,
+	}
+}
+
+// MakeRequest attempts a Twilio SendGrid request synchronously with the
+// client's transport, retry policy and error settings.
+func (cl *Client) MakeRequest(request rest.Request) (*rest.Response, error) {
+	return cl.MakeRequestWithContext(context.Background(), request)
+}
+
+// MakeRequestWithContext is like MakeRequest, aborting if ctx is cancelled
+// or its deadline passes.
+func (cl *Client) MakeRequestWithContext(ctx context.Context, request rest.Request) (*rest.Response, error) {
+	var response *rest.Response
+	var err error
+	if cl.RetryPolicy != nil {
+		response, err = cl.RetryPolicy.do(ctx, cl.send, request)
+	} else {
+		response, err = cl.send(ctx, request)
+	}
+	if err == nil && cl.ReturnAPIErrors {
+		err = CheckResponse(response)
+	}
+	return response, err
+}
+
+// send makes a single request through the client's transport
+func (cl *Client) send(ctx context.Context, request rest.Request) (*rest.Response, error) {
+	if cl.restClient == nil {
+		return MakeRequestWithContext(ctx, request)
+	}
+	return cl.restClient.SendWithContext(ctx, request)
 }
 
 // NewSendClient constructs a new Twilio SendGrid client given an API key
 func NewSendClient(key string) *Client {
-	request := GetRequest(key, "/v3/mail/send", "")
+	request := GetRequest(key, mailSendEndpoint, "")
 	request.Method = "POST"
-	return &Client{request}
+	return &Client{Request: request, options: options{Key: key}}
 }
 
 // GetRequestSubuser like NewSendClient but with On-Behalf of Subuser
 // @return [Client]
 func NewSendClientSubuser(key, subuser string) *Client {
-	request := GetRequestSubuser(key, "/v3/mail/send", "", subuser)
+	request := GetRequestSubuser(key, mailSendEndpoint, "", subuser)
 	request.Method = "POST"
-	return &Client{request}
+	return &Client{Request: request, options: options{Key: key, Subuser: subuser}}
 }
 
 // DefaultClient is used if no custom HTTP client is defined
@@ -104,60 +206,59 @@ func API(request rest.Request) (*rest.Response, error) {
 
 // MakeRequest attempts a Twilio SendGrid request synchronously.
 func MakeRequest(request rest.Request) (*rest.Response, error) {
-	return DefaultClient.Send(request)
+	return MakeRequestWithContext(context.Background(), request)
+}
+
+// MakeRequestWithContext attempts a Twilio SendGrid request synchronously,
+// aborting if ctx is cancelled or its deadline passes.
+func MakeRequestWithContext(ctx context.Context, request rest.Request) (*rest.Response, error) {
+	return DefaultClient.SendWithContext(ctx, request)
 }
 
 // MakeRequestRetry a synchronous request, but retry in the event of a rate
 // limited response.
 func MakeRequestRetry(request rest.Request) (*rest.Response, error) {
-	retry := 0
-	var response *rest.Response
-	var err error
-
-	for {
-		response, err = MakeRequest(request)
-		if err != nil {
-			return nil, err
-		}
-
-		if response.StatusCode != http.StatusTooManyRequests {
-			return response, nil
-		}
-
-		if retry > rateLimitRetry {
-			return nil, errors.New("Rate limit retry exceeded")
-		}
-		retry++
+	return MakeRequestRetryWithContext(context.Background(), request)
+}
 
-		resetTime := time.Now().Add(rateLimitSleep * time.Millisecond)
+// MakeRequestRetryWithContext is like MakeRequestRetry, but both the
+// requests and the waits between them are aborted when ctx is done, in
+// which case ctx.Err() is returned.
+func MakeRequestRetryWithContext(ctx context.Context, request rest.Request) (*rest.Response, error) {
+	return MakeRequestRetryWithPolicy(ctx, request, rateLimitRetryPolicy())
+}
 
-		reset, ok := response.Headers["X-RateLimit-Reset"]
-		if ok && len(reset) > 0 {
-			t, err := strconv.Atoi(reset[0])
-			if err == nil {
-				resetTime = time.Unix(int64(t), 0)
-			}
-		}
-		time.Sleep(resetTime.Sub(time.Now()))
-	}
+// MakeRequestRetryWithPolicy a synchronous request, retrying according to
+// the given policy.
+func MakeRequestRetryWithPolicy(ctx context.Context, request rest.Request, policy *RetryPolicy) (*rest.Response, error) {
+	return policy.do(ctx, MakeRequestWithContext, request)
 }
 
 // MakeRequestAsync attempts a request asynchronously in a new go
 // routine. This function returns two channels: responses
-// and errors. This function will retry in the case of a
-// rate limit.
+// and errors. Exactly one value is sent on one of them, so reading
+// either is enough. This function will retry in the case of a
+// rate limit. To send many requests with bounded concurrency, use
+// an AsyncSender.
 func MakeRequestAsync(request rest.Request) (chan *rest.Response, chan error) {
-	r := make(chan *rest.Response)
-	e := make(chan error)
+	return MakeRequestAsyncWithContext(context.Background(), request)
+}
+
+// MakeRequestAsyncWithContext is like MakeRequestAsync, but the request and
+// any rate limit waits are aborted when ctx is done.
+func MakeRequestAsyncWithContext(ctx context.Context, request rest.Request) (chan *rest.Response, chan error) {
+	// Both channels are buffered so the go routine never blocks on
+	// a channel the caller does not read.
+	r := make(chan *rest.Response, 1)
+	e := make(chan error, 1)
 
 	go func() {
-		response, err := MakeRequestRetry(request)
+		response, err := MakeRequestRetryWithContext(ctx, request)
 		if err != nil {
 			e <- err
+			return
 		}
-		if response != nil {
-			r <- response
-		}
+		r <- response
 	}()
 
 	return r, e
@@ -2,6 +2,7 @@ package sendgrid
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -14,6 +15,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -299,6 +301,124 @@ func TestRequestAsync_rateLimit(t *testing.T) {
 	DefaultClient = rest.DefaultClient
 }
 
+func TestRequestWithContext_cancelled(t *testing.T) {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Millisecond * 200)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer fakeServer.Close()
+	request := GetRequest("SENDGRID_APIKEY", "/v3/test_endpoint", fakeServer.URL)
+	request.Method = "GET"
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*20)
+	defer cancel()
+	_, err := MakeRequestWithContext(ctx, request)
+	assert.NotNil(t, err, "A cancellation did not trigger as expected")
+	assert.True(t, strings.Contains(err.Error(), context.DeadlineExceeded.Error()), "We did not receive the context error")
+}
+
+func TestRequestRetryWithContext_rateLimitCancelled(t *testing.T) {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Reset", strconv.Itoa(int(time.Now().Add(30*time.Second).Unix())))
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer fakeServer.Close()
+	request := GetRequest("SENDGRID_APIKEY", "/v3/test_endpoint", fakeServer.URL)
+	request.Method = "GET"
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+	start := time.Now()
+	_, err := MakeRequestRetryWithContext(ctx, request)
+	assert.Equal(t, context.DeadlineExceeded, err, "We did not receive the context error")
+	assert.True(t, time.Since(start) < 5*time.Second, "The rate limit wait was not aborted")
+}
+
+func TestRequestAsyncWithContext_cancelled(t *testing.T) {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer fakeServer.Close()
+	request := GetRequest("SENDGRID_APIKEY", "/v3/test_endpoint", fakeServer.URL)
+	request.Method = "GET"
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r, e := MakeRequestAsyncWithContext(ctx, request)
+
+	select {
+	case <-r:
+		t.Error("Received a valid response")
+	case err := <-e:
+		assert.Equal(t, context.Canceled, err, "We did not receive the context error")
+	case <-time.After(10 * time.Second):
+		t.Error("Timed out waiting for an error")
+	}
+}
+
+func TestSendWithContext_cancelled(t *testing.T) {
+	client := NewSendClient("SENDGRID_APIKEY")
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer fakeServer.Close()
+	client.BaseURL = fakeServer.URL + "/v3/mail/send"
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	from := mail.NewEmail("Example User", "test@example.com")
+	to := mail.NewEmail("Example User", "test@example.com")
+	_, err := client.SendWithContext(ctx, mail.NewSingleEmail(from, "subject", to, "text", "<p>html</p>"))
+	assert.NotNil(t, err, "A cancellation did not trigger as expected")
+	assert.True(t, strings.Contains(err.Error(), context.Canceled.Error()), "We did not receive the context error")
+}
+
+func TestSendWithContext_concurrent(t *testing.T) {
+	var mu sync.Mutex
+	subjects := make(map[string]bool)
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m, err := mail.ParseRequestBody(readBody(r))
+		if err == nil {
+			mu.Lock()
+			subjects[m.Subject] = true
+			mu.Unlock()
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer fakeServer.Close()
+	client := New("SENDGRID_APIKEY", WithHost(fakeServer.URL))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			from := mail.NewEmail("Example User", "test@example.com")
+			to := mail.NewEmail("Example User", "test@example.com")
+			_, err := client.SendWithContext(context.Background(), mail.NewSingleEmail(from, fmt.Sprint("subject ", i), to, "text", "<p>html</p>"))
+			assert.Nil(t, err)
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, 10, len(subjects), "Every send should post its own body")
+	assert.Nil(t, client.Body, "Sending should not modify the client")
+}
+
+func TestSend_marshalError(t *testing.T) {
+	var calls int
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer fakeServer.Close()
+	client := NewSendClient("SENDGRID_APIKEY")
+	client.BaseURL = fakeServer.URL + "/v3/mail/send"
+	from := mail.NewEmail("Example User", "test@example.com")
+	to := mail.NewEmail("Example User", "test@example.com")
+	email := mail.NewSingleEmail(from, "subject", to, "text", "<p>html</p>")
+	email.Personalizations[0].SetDynamicTemplateData("callback", func() {})
+	response, err := client.Send(email)
+	assert.NotNil(t, err, "The marshal error should be returned")
+	assert.Nil(t, response)
+	assert.Equal(t, 0, calls, "An empty body should not be sent")
+}
+
 func Test_test_access_settings_activity_get(t *testing.T) {
 	apiKey := "SENDGRID_APIKEY"
 	host := "http://localhost:4010"
@@ -0,0 +1,220 @@
+package sendgrid
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/sendgrid/sendgrid-go/helpers/mail"
+)
+
+// Sender sends messages through Twilio SendGrid. *Client sends them to the
+// v3 mail/send endpoint and *SMTPSender through the SMTP relay.
+type Sender interface {
+	SendMailWithContext(ctx context.Context, email *mail.SGMailV3) (*SendResult, error)
+}
+
+var (
+	_ Sender = (*Client)(nil)
+	_ Sender = (*SMTPSender)(nil)
+)
+
+// DefaultSMTPAddr is the address of the Twilio SendGrid SMTP relay
+const DefaultSMTPAddr = "smtp.sendgrid.net:587"
+
+// smtpUsername is the username of API key authentication on the SMTP relay
+const smtpUsername = "apikey"
+
+// smtpQueuedPrefix precedes the message ID in the reply to DATA
+const smtpQueuedPrefix = "queued as "
+
+// SMTPSender sends messages through the SMTP relay. Each personalization is
+// rendered to a separate MIME message, with the settings the relay can not
+// read from the message itself carried in the X-SMTPAPI header.
+type SMTPSender struct {
+	// Addr is the host:port of the relay, DefaultSMTPAddr by default
+	Addr string
+	// Key is the API key used as the password
+	Key string
+	// TLSConfig is used for STARTTLS. By default the server name is the host
+	// of Addr.
+	TLSConfig *tls.Config
+	// ValidateMail makes SendMailWithContext validate messages before
+	// sending them
+	ValidateMail bool
+}
+
+// NewSMTPSender returns a sender for the SMTP relay authenticating with key
+func NewSMTPSender(key string) *SMTPSender {
+	return &SMTPSender{Addr: DefaultSMTPAddr, Key: key}
+}
+
+// SendMailWithContext sends one message per personalization over a single
+// connection. The result holds the message ID of the first one, the IDs of
+// all of them in MessageIDs, and a StatusCode of 250, the SMTP reply code of
+// an accepted message. When sending stops with an error after some messages
+// were accepted, the result listing them is returned along with the error.
+func (s *SMTPSender) SendMailWithContext(ctx context.Context, email *mail.SGMailV3) (*SendResult, error) {
+	if s.ValidateMail {
+		if err := email.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if email.From == nil || email.From.Address == "" {
+		return nil, errors.New("sendgrid: a from address is required to send over SMTP")
+	}
+	if len(email.Personalizations) == 0 {
+		return nil, errors.New("sendgrid: at least one personalization is required to send over SMTP")
+	}
+
+	c, stop, err := s.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer stop()
+	defer c.Close()
+
+	var result *SendResult
+	for _, p := range email.Personalizations {
+		if p == nil {
+			continue
+		}
+		id, err := sendPersonalization(c, email, p)
+		if err != nil {
+			return result, contextError(ctx, err)
+		}
+		if result == nil {
+			result = &SendResult{StatusCode: 250, MessageID: id}
+		}
+		result.MessageIDs = append(result.MessageIDs, id)
+	}
+	if err := c.Quit(); err != nil {
+		return result, contextError(ctx, err)
+	}
+	return result, nil
+}
+
+// dial connects and authenticates to the relay. Expiring the connection
+// deadline once ctx is done aborts any command in progress, so an error
+// caused by ctx is only seen after ctx.Err is set; the returned function
+// stops watching ctx.
+func (s *SMTPSender) dial(ctx context.Context) (*smtp.Client, func(), error) {
+	addr := s.Addr
+	if addr == "" {
+		addr = DefaultSMTPAddr
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.SetDeadline(time.Now())
+		case <-done:
+		}
+	}()
+	stop := func() { close(done) }
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		stop()
+		conn.Close()
+		return nil, nil, contextError(ctx, err)
+	}
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		config := s.TLSConfig
+		if config == nil {
+			config = &tls.Config{ServerName: host}
+		}
+		if err := c.StartTLS(config); err != nil {
+			stop()
+			c.Close()
+			return nil, nil, contextError(ctx, err)
+		}
+	}
+	if err := c.Auth(smtp.PlainAuth("", smtpUsername, s.Key, host)); err != nil {
+		stop()
+		c.Close()
+		return nil, nil, contextError(ctx, err)
+	}
+	return c, stop, nil
+}
+
+// sendPersonalization sends the message rendered for p and returns the ID
+// the relay assigned to it
+func sendPersonalization(c *smtp.Client, email *mail.SGMailV3, p *mail.Personalization) (string, error) {
+	msg, err := email.RenderMIME(p)
+	if err != nil {
+		return "", err
+	}
+	if err := c.Mail(email.From.Address); err != nil {
+		return "", err
+	}
+	for _, recipients := range [][]*mail.Email{p.To, p.CC, p.BCC} {
+		for _, e := range recipients {
+			if e == nil {
+				continue
+			}
+			if err := c.Rcpt(e.Address); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	// smtp.Client.Data discards the final reply, which holds the message ID
+	id, err := c.Text.Cmd("DATA")
+	if err != nil {
+		return "", err
+	}
+	c.Text.StartResponse(id)
+	_, _, err = c.Text.ReadResponse(354)
+	c.Text.EndResponse(id)
+	if err != nil {
+		return "", err
+	}
+	w := c.Text.DotWriter()
+	if _, err := w.Write(msg); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	_, reply, err := c.Text.ReadResponse(250)
+	if err != nil {
+		return "", err
+	}
+	return parseQueuedID(reply), nil
+}
+
+// parseQueuedID extracts the message ID from a "Ok: queued as <id>" reply
+func parseQueuedID(reply string) string {
+	i := strings.Index(reply, smtpQueuedPrefix)
+	if i < 0 {
+		return ""
+	}
+	fields := strings.Fields(reply[i+len(smtpQueuedPrefix):])
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+// contextError returns the error of ctx when it caused err
+func contextError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
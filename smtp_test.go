@@ -0,0 +1,244 @@
+package sendgrid
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	netmail "net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sendgrid/sendgrid-go/helpers/mail"
+	"github.com/stretchr/testify/assert"
+)
+
+// smtpMessage is a message received by fakeSMTPServer
+type smtpMessage struct {
+	auth string
+	from string
+	rcpt []string
+	data string
+}
+
+// fakeSMTPServer is a minimal in-process SMTP server accepting any message
+type fakeSMTPServer struct {
+	addr     string
+	listener net.Listener
+
+	mu       sync.Mutex
+	messages []smtpMessage
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeSMTPServer{addr: l.Addr().String(), listener: l}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeSMTPServer) Close() {
+	s.listener.Close()
+}
+
+func (s *fakeSMTPServer) Messages() []smtpMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]smtpMessage(nil), s.messages...)
+}
+
+func (s *fakeSMTPServer) serve(conn net.Conn) {
+	c := textproto.NewConn(conn)
+	defer c.Close()
+	c.PrintfLine("220 localhost ESMTP")
+	var auth string
+	var msg smtpMessage
+	for {
+		line, err := c.ReadLine()
+		if err != nil {
+			return
+		}
+		verb := strings.ToUpper(strings.Fields(line + " ")[0])
+		switch verb {
+		case "EHLO", "HELO":
+			c.PrintfLine("250-localhost")
+			c.PrintfLine("250 AUTH PLAIN")
+		case "AUTH":
+			parts := strings.Fields(line)
+			b, _ := base64.StdEncoding.DecodeString(parts[len(parts)-1])
+			auth = string(b)
+			c.PrintfLine("235 Authentication successful")
+		case "MAIL":
+			msg = smtpMessage{auth: auth, from: strings.Trim(line[len("MAIL FROM:"):], "<>")}
+			c.PrintfLine("250 Sender address accepted")
+		case "RCPT":
+			if strings.Contains(line, "reject@") {
+				c.PrintfLine("550 Recipient address rejected")
+				continue
+			}
+			msg.rcpt = append(msg.rcpt, strings.Trim(line[len("RCPT TO:"):], "<>"))
+			c.PrintfLine("250 Recipient address accepted")
+		case "DATA":
+			c.PrintfLine("354 Continue")
+			data, err := c.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			msg.data = string(data)
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			n := len(s.messages)
+			s.mu.Unlock()
+			c.PrintfLine("250 Ok: queued as message-%d", n)
+		case "QUIT":
+			c.PrintfLine("221 Bye")
+			return
+		default:
+			c.PrintfLine("502 Command not implemented")
+		}
+	}
+}
+
+func TestSMTPSender(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	defer server.Close()
+
+	email := testEmail()
+	email.AddCategories("welcome")
+	email.SetTrackingSettings(mail.NewTrackingSettings().SetOpenTracking(mail.NewOpenTrackingSetting().SetEnable(true)))
+	p := mail.NewPersonalization()
+	p.AddTos(mail.NewEmail("", "second@example.com"))
+	p.AddBCCs(mail.NewEmail("", "hidden@example.com"))
+	p.SetCustomArg("user_id", "42")
+	email.AddPersonalizations(p)
+
+	sender := NewSMTPSender("SENDGRID_APIKEY")
+	sender.Addr = server.addr
+	var s Sender = sender
+	result, err := s.SendMailWithContext(context.Background(), email)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, 250, result.StatusCode)
+	assert.Equal(t, "message-1", result.MessageID)
+	assert.Equal(t, []string{"message-1", "message-2"}, result.MessageIDs)
+
+	messages := server.Messages()
+	if !assert.Equal(t, 2, len(messages)) {
+		return
+	}
+	assert.Equal(t, "\x00apikey\x00SENDGRID_APIKEY", messages[0].auth)
+	assert.Equal(t, "test@example.com", messages[0].from)
+	assert.Equal(t, []string{"test@example.com"}, messages[0].rcpt)
+	assert.Equal(t, []string{"second@example.com", "hidden@example.com"}, messages[1].rcpt)
+
+	parsed, err := netmail.ReadMessage(strings.NewReader(messages[1].data))
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, "", parsed.Header.Get("Bcc"))
+	var smtpapi mail.SMTPAPIHeader
+	if assert.Nil(t, json.Unmarshal([]byte(parsed.Header.Get("X-Smtpapi")), &smtpapi)) {
+		assert.Equal(t, []string{"welcome"}, smtpapi.Category)
+		assert.Equal(t, map[string]string{"user_id": "42"}, smtpapi.UniqueArgs)
+		assert.Equal(t, float64(1), smtpapi.Filters["opentrack"].Settings["enable"])
+	}
+}
+
+func TestSMTPSender_partial(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	defer server.Close()
+
+	email := testEmail()
+	p := mail.NewPersonalization()
+	p.AddTos(mail.NewEmail("", "reject@example.com"))
+	email.AddPersonalizations(p, mail.NewPersonalization())
+
+	sender := NewSMTPSender("SENDGRID_APIKEY")
+	sender.Addr = server.addr
+	result, err := sender.SendMailWithContext(context.Background(), email)
+	if assert.NotNil(t, err) {
+		protoErr, ok := err.(*textproto.Error)
+		if assert.True(t, ok, "The relay error should be returned") {
+			assert.Equal(t, 550, protoErr.Code)
+		}
+	}
+	if assert.NotNil(t, result, "The messages already accepted should be returned") {
+		assert.Equal(t, "message-1", result.MessageID)
+		assert.Equal(t, []string{"message-1"}, result.MessageIDs)
+	}
+	assert.Equal(t, 1, len(server.Messages()))
+}
+
+func TestSMTPSender_validation(t *testing.T) {
+	sender := NewSMTPSender("SENDGRID_APIKEY")
+	sender.Addr = "127.0.0.1:0"
+	sender.ValidateMail = true
+	_, err := sender.SendMailWithContext(context.Background(), mail.NewV3Mail())
+	_, ok := err.(mail.ValidationErrors)
+	assert.True(t, ok, "Validation errors should be returned before dialing")
+}
+
+func TestSMTPSender_cancelled(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		// accept and never greet
+		conn, err := l.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(time.Second)
+		}
+	}()
+
+	sender := NewSMTPSender("SENDGRID_APIKEY")
+	sender.Addr = l.Addr().String()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = sender.SendMailWithContext(ctx, testEmail())
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestSender_http(t *testing.T) {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Message-Id", "W7VuvHJuQDqMxaPHzGPwgw")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer fakeServer.Close()
+	var s Sender = New("SENDGRID_APIKEY", WithHost(fakeServer.URL))
+	result, err := s.SendMailWithContext(context.Background(), testEmail())
+	assert.Nil(t, err)
+	assert.Equal(t, "W7VuvHJuQDqMxaPHzGPwgw", result.MessageID)
+}
+
+func TestParseQueuedID(t *testing.T) {
+	tests := map[string]string{
+		"Ok: queued as W7VuvHJuQDqMxaPHzGPwgw": "W7VuvHJuQDqMxaPHzGPwgw",
+		"Ok: queued as abc extra":              "abc",
+		"Ok":                                   "",
+		"Ok: queued as ":                       "",
+	}
+	for reply, expected := range tests {
+		assert.Equal(t, expected, parseQueuedID(reply), fmt.Sprintf("reply %q", reply))
+	}
+}
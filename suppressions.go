@@ -0,0 +1,254 @@
+package sendgrid
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/sendgrid/rest"
+)
+
+// SuppressionKind is a list of suppressed addresses
+type SuppressionKind string
+
+// Kinds of suppressions
+const (
+	Blocks             SuppressionKind = "blocks"
+	Bounces            SuppressionKind = "bounces"
+	InvalidEmails      SuppressionKind = "invalid_emails"
+	SpamReports        SuppressionKind = "spam_reports"
+	GlobalUnsubscribes SuppressionKind = "unsubscribes"
+)
+
+// SuppressionKinds are all the kinds of suppressions
+var SuppressionKinds = []SuppressionKind{Blocks, Bounces, InvalidEmails, SpamReports, GlobalUnsubscribes}
+
+// Suppression is a suppressed address. Reason and Status are set for
+// blocks, bounces and invalid emails; IP for spam reports.
+type Suppression struct {
+	Email   string
+	Created time.Time
+	Reason  string
+	Status  string
+	IP      string
+}
+
+// UnmarshalJSON decodes the created field from Unix seconds
+func (s *Suppression) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		Email   string `json:"email"`
+		Created int64  `json:"created"`
+		Reason  string `json:"reason"`
+		Status  string `json:"status"`
+		IP      string `json:"ip"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	*s = Suppression{Email: raw.Email, Reason: raw.Reason, Status: raw.Status, IP: raw.IP}
+	if raw.Created != 0 {
+		s.Created = time.Unix(raw.Created, 0)
+	}
+	return nil
+}
+
+// maxSuppressionPageSize is the largest page of suppressions
+const maxSuppressionPageSize = 500
+
+// SuppressionListOptions filters the suppressions that are listed. Zero
+// values are not sent.
+type SuppressionListOptions struct {
+	// Start and End limit the suppressions to those created in between
+	Start  time.Time
+	End    time.Time
+	Limit  int
+	Offset int
+}
+
+func (o *SuppressionListOptions) queryParams() map[string]string {
+	params := make(map[string]string)
+	if o == nil {
+		return params
+	}
+	if !o.Start.IsZero() {
+		params["start_time"] = strconv.FormatInt(o.Start.Unix(), 10)
+	}
+	if !o.End.IsZero() {
+		params["end_time"] = strconv.FormatInt(o.End.Unix(), 10)
+	}
+	if o.Limit > 0 {
+		params["limit"] = strconv.Itoa(o.Limit)
+	}
+	if o.Offset > 0 {
+		params["offset"] = strconv.Itoa(o.Offset)
+	}
+	return params
+}
+
+// SuppressionLister lists the addresses of a kind of suppressions
+type SuppressionLister interface {
+	Kind() SuppressionKind
+	List(ctx context.Context, opts *SuppressionListOptions) ([]*Suppression, error)
+}
+
+var _ SuppressionLister = (*SuppressionList)(nil)
+
+// SuppressionsService manages the suppressions of the account through
+// /v3/suppression
+type SuppressionsService struct {
+	client *Client
+}
+
+// Suppressions returns the service managing the suppressions of the
+// account
+func (cl *Client) Suppressions() *SuppressionsService {
+	return &SuppressionsService{client: cl}
+}
+
+// Kind returns the list of suppressions of the given kind
+func (s *SuppressionsService) Kind(kind SuppressionKind) *SuppressionList {
+	return &SuppressionList{client: s.client, kind: kind}
+}
+
+// Blocks returns the addresses whose server blocked a message
+func (s *SuppressionsService) Blocks() *SuppressionList {
+	return s.Kind(Blocks)
+}
+
+// Bounces returns the addresses that bounced
+func (s *SuppressionsService) Bounces() *SuppressionList {
+	return s.Kind(Bounces)
+}
+
+// InvalidEmails returns the addresses that are not valid
+func (s *SuppressionsService) InvalidEmails() *SuppressionList {
+	return s.Kind(InvalidEmails)
+}
+
+// SpamReports returns the addresses that marked a message as spam
+func (s *SuppressionsService) SpamReports() *SuppressionList {
+	return s.Kind(SpamReports)
+}
+
+// GlobalUnsubscribes returns the addresses that unsubscribed from all
+// messages
+func (s *SuppressionsService) GlobalUnsubscribes() *SuppressionList {
+	return s.Kind(GlobalUnsubscribes)
+}
+
+// All returns the lists of every kind of suppressions
+func (s *SuppressionsService) All() []SuppressionLister {
+	lists := make([]SuppressionLister, len(SuppressionKinds))
+	for i, kind := range SuppressionKinds {
+		lists[i] = s.Kind(kind)
+	}
+	return lists
+}
+
+// SuppressionList is the list of suppressions of a kind
+type SuppressionList struct {
+	client *Client
+	kind   SuppressionKind
+}
+
+// Kind returns the kind of the suppressions
+func (l *SuppressionList) Kind() SuppressionKind {
+	return l.kind
+}
+
+// List lists the suppressions matching opts, which may be nil
+func (l *SuppressionList) List(ctx context.Context, opts *SuppressionListOptions) ([]*Suppression, error) {
+	var suppressions []*Suppression
+	if _, err := l.client.doJSON(ctx, rest.Get, l.endpoint(), opts.queryParams(), nil, &suppressions); err != nil {
+		return nil, err
+	}
+	return suppressions, nil
+}
+
+// Iterate returns an iterator over every suppression matching opts,
+// which may be nil. The Limit of opts is the page size, 500 if zero, and
+// its Offset the first suppression.
+func (l *SuppressionList) Iterate(ctx context.Context, opts *SuppressionListOptions) *Iterator[*Suppression] {
+	params := opts.queryParams()
+	pageSize := maxSuppressionPageSize
+	if limit, err := strconv.Atoi(params["limit"]); err == nil {
+		pageSize = limit
+	}
+	return Paginate[*Suppression](ctx, l.client, l.endpoint(), &PageOptions{QueryParams: params, PageSize: pageSize})
+}
+
+// Get returns the suppression of email, or nil if it is not suppressed
+func (l *SuppressionList) Get(ctx context.Context, email string) (*Suppression, error) {
+	if l.kind == GlobalUnsubscribes {
+		var unsubscribe struct {
+			Email string `json:"recipient_email"`
+		}
+		if _, err := l.client.doJSON(ctx, rest.Get, globalSuppressionEndpoint(email), nil, nil, &unsubscribe); err != nil {
+			return nil, err
+		}
+		if unsubscribe.Email == "" {
+			return nil, nil
+		}
+		return &Suppression{Email: unsubscribe.Email}, nil
+	}
+
+	var suppressions []*Suppression
+	if _, err := l.client.doJSON(ctx, rest.Get, l.emailEndpoint(email), nil, nil, &suppressions); err != nil {
+		return nil, err
+	}
+	if len(suppressions) == 0 {
+		return nil, nil
+	}
+	return suppressions[0], nil
+}
+
+// Delete removes emails from the suppressions. Global unsubscribes are
+// removed one request per address.
+func (l *SuppressionList) Delete(ctx context.Context, emails ...string) error {
+	if len(emails) == 0 {
+		return nil
+	}
+	if l.kind == GlobalUnsubscribes {
+		for _, email := range emails {
+			if _, err := l.client.doJSON(ctx, rest.Delete, globalSuppressionEndpoint(email), nil, nil, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if len(emails) == 1 {
+		_, err := l.client.doJSON(ctx, rest.Delete, l.emailEndpoint(emails[0]), nil, nil, nil)
+		return err
+	}
+	_, err := l.client.doJSON(ctx, rest.Delete, l.endpoint(), nil, map[string][]string{"emails": emails}, nil)
+	return err
+}
+
+// DeleteAll removes every address from the suppressions. Global
+// unsubscribes can not be removed at once.
+func (l *SuppressionList) DeleteAll(ctx context.Context) error {
+	if l.kind == GlobalUnsubscribes {
+		return errors.New("sendgrid: global unsubscribes can not be deleted at once")
+	}
+	_, err := l.client.doJSON(ctx, rest.Delete, l.endpoint(), nil, map[string]bool{"delete_all": true}, nil)
+	return err
+}
+
+func (l *SuppressionList) endpoint() string {
+	return "/v3/suppression/" + string(l.kind)
+}
+
+func (l *SuppressionList) emailEndpoint(email string) string {
+	if l.kind == SpamReports {
+		// the endpoint of a single spam report is singular
+		return "/v3/suppression/spam_report/" + url.PathEscape(email)
+	}
+	return l.endpoint() + "/" + url.PathEscape(email)
+}
+
+func globalSuppressionEndpoint(email string) string {
+	return "/v3/asm/suppressions/global/" + url.PathEscape(email)
+}
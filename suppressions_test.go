@@ -0,0 +1,220 @@
+package sendgrid
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSuppressions emulates the suppression and global suppression
+// endpoints
+type fakeSuppressions struct {
+	*httptest.Server
+	mu       sync.Mutex
+	lists    map[string]map[string]int64
+	requests []string
+}
+
+func newFakeSuppressions() *fakeSuppressions {
+	s := &fakeSuppressions{lists: make(map[string]map[string]int64)}
+	for _, kind := range SuppressionKinds {
+		s.lists[string(kind)] = map[string]int64{
+			"old@example.com": 1500000000,
+			"new@example.com": 1600000000,
+		}
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+func (s *fakeSuppressions) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, r.Method+" "+r.URL.RequestURI())
+
+	if email := strings.TrimPrefix(r.URL.Path, "/v3/asm/suppressions/global/"); email != r.URL.Path {
+		list := s.lists["unsubscribes"]
+		if r.Method == "DELETE" {
+			delete(list, email)
+			w.WriteHeader(http.StatusNoContent)
+		} else if _, ok := list[email]; ok {
+			fmt.Fprintf(w, `{"recipient_email":%q}`, email)
+		} else {
+			fmt.Fprint(w, `{}`)
+		}
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/v3/suppression/")
+	path = strings.Replace(path, "spam_report/", "spam_reports/", 1)
+	parts := strings.SplitN(path, "/", 2)
+	list, ok := s.lists[parts[0]]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	record := func(email string) map[string]interface{} {
+		return map[string]interface{}{"email": email, "created": list[email], "reason": "550 " + parts[0], "status": "5.0.0"}
+	}
+
+	switch {
+	case r.Method == "GET" && len(parts) == 2:
+		records := []interface{}{}
+		if _, ok := list[parts[1]]; ok {
+			records = append(records, record(parts[1]))
+		}
+		json.NewEncoder(w).Encode(records)
+	case r.Method == "GET":
+		var start, end int64
+		fmt.Sscan(r.URL.Query().Get("start_time"), &start)
+		fmt.Sscan(r.URL.Query().Get("end_time"), &end)
+		var emails []string
+		for email, created := range list {
+			if created >= start && (end == 0 || created <= end) {
+				emails = append(emails, email)
+			}
+		}
+		sort.Strings(emails)
+		records := []interface{}{}
+		for _, email := range emails {
+			records = append(records, record(email))
+		}
+		json.NewEncoder(w).Encode(records)
+	case r.Method == "DELETE" && len(parts) == 2:
+		delete(list, parts[1])
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == "DELETE":
+		var in struct {
+			DeleteAll bool     `json:"delete_all"`
+			Emails    []string `json:"emails"`
+		}
+		json.NewDecoder(r.Body).Decode(&in)
+		if in.DeleteAll {
+			s.lists[parts[0]] = map[string]int64{}
+		}
+		for _, email := range in.Emails {
+			delete(list, email)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestSuppressions_list(t *testing.T) {
+	fakeServer := newFakeSuppressions()
+	defer fakeServer.Close()
+	suppressions := New("SENDGRID_APIKEY", WithHost(fakeServer.URL)).Suppressions()
+	ctx := context.Background()
+
+	lists := suppressions.All()
+	if !assert.Equal(t, 5, len(lists)) {
+		return
+	}
+	for i, list := range lists {
+		assert.Equal(t, SuppressionKinds[i], list.Kind())
+		records, err := list.List(ctx, nil)
+		if assert.Nil(t, err) && assert.Equal(t, 2, len(records), list.Kind()) {
+			assert.Equal(t, "new@example.com", records[0].Email)
+			assert.Equal(t, time.Unix(1600000000, 0), records[0].Created)
+			assert.Equal(t, "550 "+string(list.Kind()), records[0].Reason)
+			assert.Equal(t, "5.0.0", records[0].Status)
+		}
+	}
+
+	fakeServer.requests = nil
+	records, err := suppressions.Bounces().List(ctx, &SuppressionListOptions{
+		Start: time.Unix(1550000000, 0),
+		Limit: 10,
+	})
+	assert.Nil(t, err)
+	if assert.Equal(t, 1, len(records)) {
+		assert.Equal(t, "new@example.com", records[0].Email)
+	}
+	records, err = suppressions.Bounces().List(ctx, &SuppressionListOptions{
+		End:    time.Unix(1550000000, 0),
+		Offset: 5,
+	})
+	assert.Nil(t, err)
+	if assert.Equal(t, 1, len(records)) {
+		assert.Equal(t, "old@example.com", records[0].Email)
+	}
+	assert.Equal(t, []string{
+		"GET /v3/suppression/bounces?limit=10&start_time=1550000000",
+		"GET /v3/suppression/bounces?end_time=1550000000&offset=5",
+	}, fakeServer.requests)
+}
+
+func TestSuppressions_get(t *testing.T) {
+	fakeServer := newFakeSuppressions()
+	defer fakeServer.Close()
+	suppressions := New("SENDGRID_APIKEY", WithHost(fakeServer.URL)).Suppressions()
+	ctx := context.Background()
+
+	for _, kind := range SuppressionKinds {
+		record, err := suppressions.Kind(kind).Get(ctx, "old@example.com")
+		if assert.Nil(t, err, kind) && assert.NotNil(t, record, kind) {
+			assert.Equal(t, "old@example.com", record.Email)
+		}
+		record, err = suppressions.Kind(kind).Get(ctx, "other@example.com")
+		assert.Nil(t, err, kind)
+		assert.Nil(t, record, kind)
+	}
+	assert.Contains(t, fakeServer.requests, "GET /v3/suppression/spam_report/old@example.com")
+	assert.Contains(t, fakeServer.requests, "GET /v3/asm/suppressions/global/old@example.com")
+}
+
+func TestSuppressions_delete(t *testing.T) {
+	fakeServer := newFakeSuppressions()
+	defer fakeServer.Close()
+	suppressions := New("SENDGRID_APIKEY", WithHost(fakeServer.URL)).Suppressions()
+	ctx := context.Background()
+
+	assert.Nil(t, suppressions.Blocks().Delete(ctx))
+	assert.Nil(t, suppressions.Blocks().Delete(ctx, "old@example.com", "new@example.com"))
+	assert.Nil(t, suppressions.SpamReports().Delete(ctx, "old@example.com"))
+	assert.Nil(t, suppressions.Bounces().DeleteAll(ctx))
+	assert.Nil(t, suppressions.GlobalUnsubscribes().Delete(ctx, "old@example.com", "new@example.com"))
+	assert.NotNil(t, suppressions.GlobalUnsubscribes().DeleteAll(ctx))
+
+	assert.Equal(t, []string{
+		"DELETE /v3/suppression/blocks",
+		"DELETE /v3/suppression/spam_report/old@example.com",
+		"DELETE /v3/suppression/bounces",
+		"DELETE /v3/asm/suppressions/global/old@example.com",
+		"DELETE /v3/asm/suppressions/global/new@example.com",
+	}, fakeServer.requests)
+	assert.Equal(t, 0, len(fakeServer.lists["blocks"]))
+	assert.Equal(t, 0, len(fakeServer.lists["bounces"]))
+	assert.Equal(t, 1, len(fakeServer.lists["spam_reports"]))
+	assert.Equal(t, 0, len(fakeServer.lists["unsubscribes"]))
+	assert.Equal(t, 2, len(fakeServer.lists["invalid_emails"]))
+}
+
+func TestSuppressions_errors(t *testing.T) {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"errors":[{"field":null,"message":"authorization required"}]}`)
+	}))
+	defer fakeServer.Close()
+	suppressions := New("SENDGRID_APIKEY", WithHost(fakeServer.URL)).Suppressions()
+	ctx := context.Background()
+
+	_, err := suppressions.InvalidEmails().List(ctx, nil)
+	assert.True(t, IsUnauthorized(err))
+	_, err = suppressions.InvalidEmails().Get(ctx, "old@example.com")
+	assert.True(t, IsUnauthorized(err))
+	_, err = suppressions.GlobalUnsubscribes().Get(ctx, "old@example.com")
+	assert.True(t, IsUnauthorized(err))
+	assert.True(t, IsUnauthorized(suppressions.GlobalUnsubscribes().Delete(ctx, "old@example.com")))
+
+	var s Suppression
+	assert.NotNil(t, json.Unmarshal([]byte(`{"created":"yesterday"}`), &s))
+}
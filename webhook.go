@@ -0,0 +1,24 @@
+package sendgrid
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sendgrid/rest"
+)
+
+// EventWebhookPublicKey returns the base64 public key the Event Webhook
+// signs requests with, to be passed to webhook.NewVerifier. It fails if
+// the signed Event Webhook is not enabled.
+func (cl *Client) EventWebhookPublicKey(ctx context.Context) (string, error) {
+	var settings struct {
+		PublicKey string `json:"public_key"`
+	}
+	if _, err := cl.doJSON(ctx, rest.Get, "/v3/user/webhooks/event/settings/signed", nil, nil, &settings); err != nil {
+		return "", err
+	}
+	if settings.PublicKey == "" {
+		return "", errors.New("sendgrid: the signed Event Webhook is not enabled")
+	}
+	return settings.PublicKey, nil
+}
@@ -0,0 +1,31 @@
+package sendgrid
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventWebhookPublicKey(t *testing.T) {
+	publicKey := "MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAEEDr2LjtURuePQzplybdC+u4CwrqDqBaWjcMMsTbhdbcwHBcepxo7yAQGhHPTnlvFYPAZFceEu/1FwCM/QmGUhA=="
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v3/user/webhooks/event/settings/signed", r.URL.Path)
+		if r.Header.Get("Authorization") == "Bearer DISABLED" {
+			fmt.Fprint(w, `{"public_key":""}`)
+			return
+		}
+		fmt.Fprintf(w, `{"public_key":%q}`, publicKey)
+	}))
+	defer fakeServer.Close()
+
+	key, err := New("SENDGRID_APIKEY", WithHost(fakeServer.URL)).EventWebhookPublicKey(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, publicKey, key)
+
+	_, err = New("DISABLED", WithHost(fakeServer.URL)).EventWebhookPublicKey(context.Background())
+	assert.NotNil(t, err)
+}